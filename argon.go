@@ -5,17 +5,20 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
+	"os"
 	"runtime"
 	"strconv"
 
 	"golang.org/x/crypto/argon2"
+
+	"gopkg.hlmpn.dev/pkg/argon2password/internal/cgroup"
 )
 
 // getArgonParallelism returns the recommended degree of parallelism
 // capped at a reasonable level to balance security and performance
 func getArgonParallelism() uint8 {
-	// Use number of available CPUs, with a minimum of 1 and maximum of ArgonMaxParallelism
-	n := runtime.NumCPU()
+	// Use the number of CPUs available to the process, with a minimum of 1 and maximum of ArgonMaxParallelism
+	n := cgroup.AvailableCPUs(os.DirFS("/"), runtime.GOMAXPROCS(0))
 	numCPU = n
 
 	// Cap at ArgonMaxParallelism (4)
@@ -31,8 +34,8 @@ func getArgonParallelism() uint8 {
 	return uint8(n) //nolint // G115
 }
 
-// generateArgonHash creates a hash of the password using Argon2id with the given parameters and salt
-// The password slice is zeroed out after use for security
+// generateArgonHash creates a hash of the password using Argon2id with the given parameters and salt.
+// The password is left to the caller to wipe, the returned hash should be wiped once encoded or compared.
 func generateArgonHash(password, salt []byte, iterations, memory uint32, parallelism uint8, keyLength uint32) []byte {
 	if salt == nil || len(salt) == 0 || password == nil || len(password) == 0 {
 		return nil
@@ -69,10 +72,10 @@ func encodeArgonHash(hash, salt []byte, memory, iterations uint32, parallelism u
 	)
 }
 
-func encodeArgonHashAsBytes(hash, salt []byte, memory, iterations uint32, parallelism uint8) []byte {
-	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
-	b64Hash := base64.RawStdEncoding.EncodeToString(hash)
-
+// encodeArgonHashAsBytes encodes directly into the returned slice,
+// so no intermediate copies of the salt or hash are left to wipe.
+// A normalization is recorded as an n= parameter after p=, and a pre-hash as a ph= parameter after that.
+func encodeArgonHashAsBytes(hash, salt []byte, memory, iterations uint32, parallelism uint8, normalization Normalization, preHash PreHash) []byte {
 	encodedHash := make([]byte, 0, 100) // Preallocate
 
 	encodedHash = append(encodedHash, argonAlgoAndVersionPrefixBytes...)
@@ -83,46 +86,119 @@ func encodeArgonHashAsBytes(hash, salt []byte, memory, iterations uint32, parall
 	encodedHash = strconv.AppendUint(encodedHash, uint64(iterations), 10) //nolint:mnd
 	encodedHash = append(encodedHash, commaPEqualsBytes...)
 	encodedHash = strconv.AppendUint(encodedHash, uint64(parallelism), 10) //nolint:mnd
+	if normalization != NormalizationNone {
+		encodedHash = append(encodedHash, commaNEqualsBytes...)
+		encodedHash = append(encodedHash, normalization...)
+	}
+	if preHash != PreHashNone {
+		encodedHash = append(encodedHash, commaPHEqualsBytes...)
+		encodedHash = append(encodedHash, preHash...)
+	}
 	encodedHash = append(encodedHash, dollarSignByte)
-	encodedHash = append(encodedHash, b64Salt...)
+	encodedHash = base64.RawStdEncoding.AppendEncode(encodedHash, salt)
 	encodedHash = append(encodedHash, dollarSignByte)
-	encodedHash = append(encodedHash, b64Hash...)
+	encodedHash = base64.RawStdEncoding.AppendEncode(encodedHash, hash)
 
 	return encodedHash
 }
 
+// argonHash holds the components of an encoded hash
+type argonHash struct {
+	memory        uint32
+	iterations    uint32
+	parallelism   uint8
+	salt          []byte
+	hash          []byte
+	normalization Normalization
+	preHash       PreHash
+}
+
 // decodeArgonHashBytes extracts the components from an encoded hash byte slice
+// and enforces the DoS protection limits of the default Config.
 // Returns memory, iterations, parallelism, salt, hash, error
 func decodeArgonHashBytes(encodedHash []byte) (uint32, uint32, uint8, []byte, []byte, error) {
+	config := currentConfig()
+	return decodeArgonHashBytesWithLimits(encodedHash, config.MaxMemory, config.MaxIterations)
+}
+
+// decodeArgonHashBytesWithLimits is decodeArgonHashBytes with custom DoS protection limits
+func decodeArgonHashBytesWithLimits(encodedHash []byte, maxMemory MemorySize, maxIterations uint32) (uint32, uint32, uint8, []byte, []byte, error) {
+	h, err := decodeArgonHashWithLimits(encodedHash, maxMemory, maxIterations)
+	if err != nil {
+		return 0, 0, 0, nil, nil, err
+	}
+	return h.memory, h.iterations, h.parallelism, h.salt, h.hash, nil
+}
+
+// decodeArgonHashWithLimits parses an encoded hash and enforces the DoS protection limits
+func decodeArgonHashWithLimits(encodedHash []byte, maxMemory MemorySize, maxIterations uint32) (*argonHash, error) {
+	h, err := parseArgonHashBytes(encodedHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce limits on memory and iterations to prevent DoS
+	if exceedsArgonLimits(h, maxMemory, maxIterations) {
+		return nil, ErrInvalidParams
+	}
+	return h, nil
+}
+
+// exceedsArgonLimits reports whether verifying h would cost more than the given limits
+func exceedsArgonLimits(h *argonHash, maxMemory MemorySize, maxIterations uint32) bool {
+	return MemorySize(h.memory) > maxMemory || h.iterations > maxIterations
+}
+
+// parseArgonHashBytes extracts the components from an encoded hash byte slice.
+// It does not enforce the DoS protection limits, use decodeArgonHashBytes
+// for anything that will be verified.
+func parseArgonHashBytes(encodedHash []byte) (*argonHash, error) {
 	parts := bytes.Split(encodedHash, dollarSignBytes)
 	if len(parts) != ArgonEncodedPartCount {
-		return 0, 0, 0, nil, nil, ErrInvalidHashFormat
+		return nil, ErrInvalidHashFormat
 	}
 
 	// Compare the algorithm identifier
 	if !bytes.Equal(parts[1], argon2idBytes) {
-		return 0, 0, 0, nil, nil, ErrUnsupportedAlgorithm
+		return nil, ErrUnsupportedAlgorithm
 	}
 
 	// Parse version - extract the number after "v="
 	versionBytes := parts[2]
 	if len(versionBytes) < 3 || !bytes.Equal(versionBytes[:2], vEqualsBytes) {
-		return 0, 0, 0, nil, nil, ErrInvalidVersion
+		return nil, ErrInvalidVersion
 	}
 
 	// Parse version number from bytes
 	version, err := parseUint32FromBytes(versionBytes[2:])
 	if err != nil {
-		return 0, 0, 0, nil, nil, err
+		return nil, err
 	}
 
 	// Verify that the version is supported
 	if int(version) != argon2.Version {
-		return 0, 0, 0, nil, nil, ErrInvalidVersion
+		return nil, ErrInvalidVersion
 	}
 
-	// Parse parameters - format is "m=X,t=Y,p=Z"
+	// Parse parameters - format is "m=X,t=Y,p=Z" with an optional ",n=" normalization
+	// and an optional ",ph=" pre-hash, in that order. They are removed from the end.
 	paramBytes := parts[3]
+	preHash := PreHashNone
+	if phPos := bytes.Index(paramBytes, commaPHEqualsBytes); phPos >= 0 {
+		preHash, err = parsePreHash(paramBytes[phPos+len(commaPHEqualsBytes):])
+		if err != nil {
+			return nil, err
+		}
+		paramBytes = paramBytes[:phPos]
+	}
+	normalization := NormalizationNone
+	if nPos := bytes.Index(paramBytes, commaNEqualsBytes); nPos >= 0 {
+		normalization, err = parseNormalization(paramBytes[nPos+len(commaNEqualsBytes):])
+		if err != nil {
+			return nil, err
+		}
+		paramBytes = paramBytes[:nPos]
+	}
 
 	// Find positions of parameter separators
 	mPos := bytes.Index(paramBytes, mEqualsBytes)
@@ -130,51 +206,46 @@ func decodeArgonHashBytes(encodedHash []byte) (uint32, uint32, uint8, []byte, []
 	pPos := bytes.Index(paramBytes, commaPEqualsBytes)
 
 	if mPos != 0 || tPos < 3 || pPos < tPos+3 {
-		return 0, 0, 0, nil, nil, ErrInvalidParams
+		return nil, ErrInvalidParams
 	}
 
 	// Extract and parse memory parameter (after "m=" and before ",t=")
 	memoryBytes := paramBytes[2:tPos]
 	memory, err := parseUint32FromBytes(memoryBytes)
 	if err != nil {
-		return 0, 0, 0, nil, nil, err
+		return nil, err
 	}
 
 	// Extract and parse iterations parameter (after ",t=" and before ",p=")
 	iterBytes := paramBytes[tPos+3 : pPos]
 	iterations, err := parseUint32FromBytes(iterBytes)
 	if err != nil {
-		return 0, 0, 0, nil, nil, err
+		return nil, err
+	}
+	// argon2.IDKey panics with less than one pass
+	if iterations < 1 {
+		return nil, ErrInvalidParams
 	}
 
 	// Extract and parse parallelism parameter (after ",p=")
 	parallelismBytes := paramBytes[pPos+3:]
 	parallelismUint32, err := parseUint32FromBytes(parallelismBytes)
 	if err != nil {
-		return 0, 0, 0, nil, nil, err
+		return nil, err
 	}
 
-	// Check for overflow when converting to uint8
-	if parallelismUint32 > 255 {
-		return 0, 0, 0, nil, nil, ErrInvalidParams
+	// Check for overflow when converting to uint8, argon2.IDKey panics without a lane
+	if parallelismUint32 < 1 || parallelismUint32 > 255 {
+		return nil, ErrInvalidParams
 	}
 	parallelism := uint8(parallelismUint32)
 
-	// Enforce limits on memory and iterations to prevent DoS
-	if memory > ArgonMaxMemory {
-		return 0, 0, 0, nil, nil, ErrInvalidParams
-	}
-
-	if iterations > ArgonMaxIterations {
-		return 0, 0, 0, nil, nil, ErrInvalidParams
-	}
-
 	// Decode base64 salt
 	saltBytes := parts[4]
 	salt := make([]byte, base64.RawStdEncoding.DecodedLen(len(saltBytes)))
 	n, err := base64.RawStdEncoding.Decode(salt, saltBytes)
 	if err != nil {
-		return 0, 0, 0, nil, nil, fmt.Errorf("Argon2Password: Base64 decode error: %w", err)
+		return nil, fmt.Errorf("Argon2Password: Base64 decode error: %w", err)
 	}
 	salt = salt[:n] // Trim to actual size
 
@@ -183,11 +254,19 @@ func decodeArgonHashBytes(encodedHash []byte) (uint32, uint32, uint8, []byte, []
 	hash := make([]byte, base64.RawStdEncoding.DecodedLen(len(hashBytes)))
 	n, err = base64.RawStdEncoding.Decode(hash, hashBytes)
 	if err != nil {
-		return 0, 0, 0, nil, nil, fmt.Errorf("Argon2Password: Base64 decode error: %w", err)
+		return nil, fmt.Errorf("Argon2Password: Base64 decode error: %w", err)
 	}
 	hash = hash[:n] // Trim to actual size
 
-	return memory, iterations, parallelism, salt, hash, nil
+	return &argonHash{
+		memory:        memory,
+		iterations:    iterations,
+		parallelism:   parallelism,
+		salt:          salt,
+		hash:          hash,
+		normalization: normalization,
+		preHash:       preHash,
+	}, nil
 }
 
 func decodeArgonHash(encodedHash string) (uint32, uint32, uint8, []byte, []byte, error) { //nolint:unused //
@@ -226,17 +305,36 @@ func parseUint32FromBytes(b []byte) (uint32, error) {
 }
 
 // compareArgonPasswordAndHash compares a password with an encoded hash
+// within the DoS protection limits of the default Config
 func compareArgonPasswordAndHash(password []byte, encodedHash []byte) (bool, error) {
+	config := currentConfig()
+	return compareArgonPasswordAndHashWithLimits(password, encodedHash, config.MaxMemory, config.MaxIterations)
+}
+
+// compareArgonPasswordAndHashWithLimits is compareArgonPasswordAndHash with custom DoS protection limits
+func compareArgonPasswordAndHashWithLimits(password []byte, encodedHash []byte, maxMemory MemorySize, maxIterations uint32) (bool, error) {
 	// Reject empty passwords
 	if len(password) == 0 {
 		return false, ErrEmptyPassword
 	}
 
 	// Decode the hash using the byte-oriented function
-	memory, iterations, parallelism, salt, hash, err := decodeArgonHashBytes(encodedHash)
+	h, err := decodeArgonHashWithLimits(encodedHash, maxMemory, maxIterations)
 	if err != nil {
 		return false, err
 	}
+	salt, hash := h.salt, h.hash
+	defer clear(salt)
+	defer clear(hash)
+
+	// Apply the normalization and pre-hash recorded in the hash, not the ones of the current Config
+	input, err := argonInput(password, h.normalization, h.preHash)
+	if err != nil {
+		return false, err
+	}
+	if h.normalization != NormalizationNone || h.preHash != PreHashNone {
+		defer clear(input)
+	}
 
 	// Safe conversion: Ensure the hash length is within uint32 limits
 	// As it could otherwise be a DoS attack vector where
@@ -248,55 +346,46 @@ func compareArgonPasswordAndHash(password []byte, encodedHash []byte) (bool, err
 	}
 
 	// Compute the hash for the provided password
-	computedHash := generateArgonHash(password, salt, iterations, memory, parallelism, uint32(hashLen))
+	computedHash := generateArgonHash(input, salt, h.iterations, h.memory, h.parallelism, uint32(hashLen))
 	if computedHash == nil {
 		return false, ErrInvalidHash
 	}
+	defer clear(computedHash)
 	// Constant-time comparison of the hashes to prevent timing attacks
 	match := subtle.ConstantTimeCompare(hash, computedHash) == 1
 	return match, nil
 }
 
+// generateHashFromInput hashes password with the current default Config, see SetDefaultConfig
 func generateHashFromInput(password []byte) ([]byte, error) {
+	return generateHashFromInputCustom(password, currentConfig())
+}
 
-	// Generate a cryptographically secure random salt
-	salt, err := generateSalt(ArgonSaltLength)
-	if err != nil {
-		return nil, ErrInvalidHash
-	}
-
-	// Generate the hash
-	hash := generateArgonHash(
-		password,                // Provided password
-		salt,                    // Generated salt
-		ArgonIterations,         // default iterations
-		ArgonMemory,             // default memory
-		argonDefaultParallelism, // default parallelism
-		ArgonKeyLength,          // default key length
-	)
-	if hash == nil {
-		return nil, ErrInvalidHash
+// generateHashFromInputCustom checks the length of password and hashes it with config
+func generateHashFromInputCustom(password []byte, config *Config) ([]byte, error) {
+	if config == nil {
+		return nil, ErrConfigNil
 	}
-
-	// Encode the hash in the standard format
-	encodedHash := encodeArgonHashAsBytes(
-		hash,                    // Generated hash
-		salt,                    // Generated salt
-		ArgonMemory,             // default memory
-		ArgonIterations,         // default iterations
-		argonDefaultParallelism, // default parallelism
-	)
-	if len(encodedHash) == 0 {
-		return nil, ErrInvalidHash
+	if err := checkPasswordLength(password, config.MaxPasswordLength); err != nil {
+		return nil, err
 	}
-	return encodedHash, nil
-
+	return generateEncodedHash(password, config)
 }
 
-func generateHashFromInputCustom(password []byte, config *Config) ([]byte, error) { //nolint:unused //
-
-	if config == nil {
-		return nil, ErrConfigNil
+// generateEncodedHash hashes input with config, applying its normalization and pre-hash.
+// It doesn't check the input length, wrapped legacy hashes are not passwords.
+func generateEncodedHash(input []byte, config *Config) ([]byte, error) {
+	// The other fields are up to the caller, but a memory in the KiB range is
+	// almost certainly a unit mistake and gives no protection at all
+	if config.Memory < argonMinMemory {
+		return nil, &ConfigError{Field: "Memory", Value: uint64(config.Memory), Err: ErrConfigMemoryTooSmall}
+	}
+	password, err := argonInput(input, config.Normalization, config.PreHash)
+	if err != nil {
+		return nil, err
+	}
+	if config.Normalization != NormalizationNone || config.PreHash != PreHashNone {
+		defer clear(password)
 	}
 
 	// Generate a cryptographically secure random salt
@@ -306,28 +395,32 @@ func generateHashFromInputCustom(password []byte, config *Config) ([]byte, error
 	}
 	// Generate the hash
 	hash := generateArgonHash(
-		password,           // Provided password
-		salt,               // Generated salt
-		config.Iterations,  //  Iterations
-		config.Memory,      //  Memory
-		config.Parallelism, //  Parallelism
-		config.KeyLength,   //  Key length
+		password,            // Provided password
+		salt,                // Generated salt
+		config.Iterations,   //  Iterations
+		config.Memory.KiB(), //  Memory
+		config.Parallelism,  //  Parallelism
+		config.KeyLength,    //  Key length
 	)
 	if hash == nil {
+		clear(salt)
 		return nil, ErrInvalidHash
 	}
 
 	// Encode the hash in the standard format
 	encodedHash := encodeArgonHashAsBytes(
-		hash,               // Generated hash
-		salt,               // Generated salt
-		config.Memory,      //  Memory
-		config.Iterations,  //  Iterations
-		config.Parallelism, //  Parallelism
+		hash,                 // Generated hash
+		salt,                 // Generated salt
+		config.Memory.KiB(),  //  Memory
+		config.Iterations,    //  Iterations
+		config.Parallelism,   //  Parallelism
+		config.Normalization, //  Normalization
+		config.PreHash,       //  Pre-hash
 	)
+	clear(hash)
+	clear(salt)
 	if len(encodedHash) == 0 {
 		return nil, ErrInvalidHash
 	}
 	return encodedHash, nil
-
 }
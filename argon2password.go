@@ -1,20 +1,25 @@
 package argon2password
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+)
 
 // Global variables assigned runtime
 // Static global consts and variables are defined in constants.go
 var (
 	// default parallelism based on the number of available CPUs
 	argonDefaultParallelism = getArgonParallelism()
-	numCPU                  int // asigned when argonDefaultParallelism is set
+	numCPU                  int // CPUs available to the process, asigned when argonDefaultParallelism is set
 )
 
 // HashPW hashes the given password using Argon2id and returns the hash along with an error if any.
 // Argon2id is the OWASP-recommended algorithm for password hashing as it provides the best
 // protection against both side-channel attacks and GPU-based attacks.
 func HashPW(password string) (string, error) {
-	hash, err := HashPWBytes([]byte(password))
+	passwordBytes := []byte(password)
+	defer clear(passwordBytes)
+	hash, err := HashPWBytes(passwordBytes)
 	if err != nil {
 		return "", fmt.Errorf("argon2Password: failed to hash password: %w", err)
 	}
@@ -45,13 +50,20 @@ func ComparePWBytes(password []byte, hash []byte) (bool, error) {
 	if hash == nil {
 		return false, ErrNilHash
 	}
-	return compareArgonPasswordAndHash(password, hash)
+	config := currentConfig()
+	if err := checkPasswordLength(password, config.MaxPasswordLength); err != nil {
+		return false, err
+	}
+	return comparePasswordAndHash(password, hash, config.MaxMemory, config.MaxIterations)
 }
 
 // ComparePW compares a given password with a stored hash.
 // This function uses a constant-time comparison to prevent timing attacks.
+// Wrapped legacy hashes are supported, see WrapLegacyHash.
 func ComparePW(password string, hash string) (bool, error) {
-	return ComparePWBytes([]byte(password), []byte(hash))
+	passwordBytes := []byte(password)
+	defer clear(passwordBytes)
+	return ComparePWBytes(passwordBytes, []byte(hash))
 }
 
 // Password generation
@@ -110,13 +122,54 @@ func HashWithConfig(password string, config *Config) (string, error) {
 	case password == "":
 		return "", ErrEmptyPassword
 	}
-	hash, err := generateHashFromInputCustom([]byte(password), config)
+	passwordBytes := []byte(password)
+	defer clear(passwordBytes)
+	hash, err := generateHashFromInputCustom(passwordBytes, config)
 	if err != nil {
 		return "", err
 	}
 	return string(hash), nil
 }
 
+// ComparePWWithConfig is ComparePW with the DoS protection limits of config,
+// MaxMemory, MaxIterations and MaxPasswordLength, instead of those of the default Config.
+// Unset(0) limits use the defaults, see SetDefaultConfig. Use it to verify hashes created with
+// parameters above the default limits, such as PresetRFC9106First.
+func ComparePWWithConfig(password string, hash string, config *Config) (bool, error) {
+	passwordBytes := []byte(password)
+	defer clear(passwordBytes)
+	return ComparePWWithConfigBytes(passwordBytes, []byte(hash), config)
+}
+
+// ComparePWWithConfigBytes is the []byte version of ComparePWWithConfig.
+func ComparePWWithConfigBytes(password []byte, hash []byte, config *Config) (bool, error) {
+	switch {
+	case config == nil:
+		return false, ErrConfigNil
+	case hash == nil:
+		return false, ErrNilHash
+	}
+	maxMemory, maxIterations := config.MaxMemory, config.MaxIterations
+	if maxMemory == 0 {
+		maxMemory = currentConfig().MaxMemory
+	}
+	if maxIterations == 0 {
+		maxIterations = currentConfig().MaxIterations
+	}
+	if err := checkPasswordLength(password, config.MaxPasswordLength); err != nil {
+		return false, err
+	}
+	return comparePasswordAndHash(password, hash, maxMemory, maxIterations)
+}
+
+// comparePasswordAndHash verifies a plain or wrapped hash within the given limits
+func comparePasswordAndHash(password []byte, hash []byte, maxMemory MemorySize, maxIterations uint32) (bool, error) {
+	if bytes.HasPrefix(hash, argon2idWrapPrefixBytes) {
+		return compareWrappedPasswordAndHash(password, hash, maxMemory, maxIterations)
+	}
+	return compareArgonPasswordAndHashWithLimits(password, hash, maxMemory, maxIterations)
+}
+
 func HashWithConfigBytes(password []byte, config *Config) ([]byte, error) {
 	switch {
 	case config == nil:
@@ -0,0 +1,123 @@
+package argon2password_test
+
+import (
+	"strings"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestClassifyHash(t *testing.T) {
+	current, err := argon2password.HashPW("password")
+	if err != nil {
+		t.Fatalf("HashPW() error = %v", err)
+	}
+	weak := mustHashLowCost(t, "password")
+
+	tests := []struct {
+		name       string
+		hash       string
+		wantClass  argon2password.AuditClass
+		wantScheme string
+	}{
+		{name: "Current argon2id", hash: current, wantClass: argon2password.AuditCurrent, wantScheme: "argon2id"},
+		{name: "Weak argon2id", hash: weak, wantClass: argon2password.AuditWeak, wantScheme: "argon2id"},
+		{
+			name:       "DoS risky argon2id",
+			hash:       "$argon2id$v=19$m=2097152,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g",
+			wantClass:  argon2password.AuditDoSRisk,
+			wantScheme: "argon2id",
+		},
+		{name: "Broken argon2id", hash: "$argon2id$v=19$m=abc", wantClass: argon2password.AuditMalformed, wantScheme: "argon2id"},
+		{name: "Zero iterations", hash: "$argon2id$v=19$m=8192,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g", wantClass: argon2password.AuditMalformed, wantScheme: "argon2id"},
+		{name: "Zero parallelism", hash: "$argon2id$v=19$m=8192,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g", wantClass: argon2password.AuditMalformed, wantScheme: "argon2id"},
+		{name: "Bcrypt", hash: "$2y$10$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyzabc", wantClass: argon2password.AuditLegacy, wantScheme: "bcrypt"},
+		{name: "Unsalted MD5", hash: "5f4dcc3b5aa765d61d8327deb882cf99", wantClass: argon2password.AuditLegacy, wantScheme: "md5"},
+		{name: "Unsalted SHA-1", hash: "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", wantClass: argon2password.AuditLegacy, wantScheme: "sha1"},
+		{name: "Argon2i", hash: "$argon2i$v=19$m=4096,t=3,p=1$c2FsdA$aGFzaA", wantClass: argon2password.AuditLegacy, wantScheme: "argon2i"},
+		{name: "Garbage", hash: "not-a-hash", wantClass: argon2password.AuditMalformed},
+		{name: "Empty", hash: "", wantClass: argon2password.AuditMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := argon2password.ClassifyHash(tt.hash, nil)
+			if result.Class != tt.wantClass {
+				t.Errorf("ClassifyHash() class = %q, want %q (reason %q)", result.Class, tt.wantClass, result.Reason)
+			}
+			if result.Scheme != tt.wantScheme {
+				t.Errorf("ClassifyHash() scheme = %q, want %q", result.Scheme, tt.wantScheme)
+			}
+		})
+	}
+
+	// A weaker reference makes the low cost hash current
+	reference := &argon2password.Config{Memory: 8 * argon2password.MiB, Iterations: 1}
+	if result := argon2password.ClassifyHash(weak, reference); result.Class != argon2password.AuditCurrent {
+		t.Errorf("ClassifyHash() with reference class = %q, want %q", result.Class, argon2password.AuditCurrent)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	weak := mustHashLowCost(t, "password")
+
+	t.Run("Lines", func(t *testing.T) {
+		input := strings.Join([]string{weak, "", "5f4dcc3b5aa765d61d8327deb882cf99", "garbage"}, "\n")
+		report, err := argon2password.Audit(strings.NewReader(input), nil)
+		if err != nil {
+			t.Fatalf("Audit() error = %v", err)
+		}
+		if report.Total != 3 {
+			t.Errorf("Audit() total = %d, want 3", report.Total)
+		}
+		want := map[argon2password.AuditClass]int{
+			argon2password.AuditCurrent:   0,
+			argon2password.AuditWeak:      1,
+			argon2password.AuditLegacy:    1,
+			argon2password.AuditMalformed: 1,
+			argon2password.AuditDoSRisk:   0,
+		}
+		for class, count := range want {
+			if report.Counts[class] != count {
+				t.Errorf("Audit() counts[%q] = %d, want %d", class, report.Counts[class], count)
+			}
+		}
+		if len(report.Results) != 3 || report.Results[1].Line != 3 {
+			t.Errorf("Audit() results = %+v, want 3 results with line numbers", report.Results)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		input := "id,hash\nalice,\"" + weak + "\"\nbob,$2b$10$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyzabc\ncarol\n"
+		report, err := argon2password.Audit(strings.NewReader(input), &argon2password.AuditOptions{
+			CSVColumn:  2,
+			IDColumn:   1,
+			SkipHeader: true,
+		})
+		if err != nil {
+			t.Fatalf("Audit() error = %v", err)
+		}
+		wantClasses := []argon2password.AuditClass{argon2password.AuditWeak, argon2password.AuditLegacy, argon2password.AuditMalformed}
+		if len(report.Results) != len(wantClasses) {
+			t.Fatalf("Audit() results = %d, want %d", len(report.Results), len(wantClasses))
+		}
+		for i, class := range wantClasses {
+			if report.Results[i].Class != class {
+				t.Errorf("Audit() results[%d].Class = %q, want %q", i, report.Results[i].Class, class)
+			}
+		}
+		if report.Results[0].ID != "alice" {
+			t.Errorf("Audit() results[0].ID = %q, want alice", report.Results[0].ID)
+		}
+	})
+
+	t.Run("Summary only", func(t *testing.T) {
+		report, err := argon2password.Audit(strings.NewReader(weak), &argon2password.AuditOptions{SummaryOnly: true})
+		if err != nil {
+			t.Fatalf("Audit() error = %v", err)
+		}
+		if report.Total != 1 || len(report.Results) != 0 {
+			t.Errorf("Audit() total = %d, results = %d, want 1 and 0", report.Total, len(report.Results))
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package argon2password_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+	"gopkg.hlmpn.dev/pkg/argon2password/basicauth"
+)
+
+// testLowCostConfig keeps the hashes used in tests cheap to verify
+func testLowCostConfig() *argon2password.Config {
+	return &argon2password.Config{
+		Memory:      8 * argon2password.MiB,
+		Iterations:  1,
+		SaltLength:  16,
+		KeyLength:   32,
+		Parallelism: 1,
+	}
+}
+
+func mustHashLowCost(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := argon2password.HashWithConfig(password, testLowCostConfig())
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+	return hash
+}
+
+func TestBasicAuthMiddleware(t *testing.T) {
+	store := basicauth.MapStore{
+		"alice": mustHashLowCost(t, "alice-password"),
+	}
+	mw, err := basicauth.Middleware(store, &basicauth.Options{Realm: "test"})
+	if err != nil {
+		t.Fatalf("Middleware() error = %v", err)
+	}
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, ok := basicauth.Username(r.Context())
+		if !ok {
+			t.Errorf("Username() not set in request context")
+		}
+		_, _ = w.Write([]byte(username))
+	}))
+
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		noAuth     bool
+		wantStatus int
+	}{
+		{name: "Valid credentials", username: "alice", password: "alice-password", wantStatus: http.StatusOK},
+		{name: "Wrong password", username: "alice", password: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "Unknown user", username: "bob", password: "alice-password", wantStatus: http.StatusUnauthorized},
+		{name: "Empty password", username: "alice", password: "", wantStatus: http.StatusUnauthorized},
+		{name: "Oversized password", username: "alice", password: strings.Repeat("a", int(argon2password.ArgonMaxPasswordLength)+1), wantStatus: http.StatusUnauthorized},
+		{name: "Oversized password of an unknown user", username: "bob", password: strings.Repeat("a", int(argon2password.ArgonMaxPasswordLength)+1), wantStatus: http.StatusUnauthorized},
+		{name: "No credentials", noAuth: true, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if !tt.noAuth {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				challenge := rec.Header().Get("WWW-Authenticate")
+				if !strings.HasPrefix(challenge, `Basic realm="test"`) {
+					t.Errorf("WWW-Authenticate = %q, want Basic realm", challenge)
+				}
+			}
+			if tt.wantStatus == http.StatusOK && rec.Body.String() != tt.username {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.username)
+			}
+		})
+	}
+}
+
+func TestBasicAuthStoreFunc(t *testing.T) {
+	storeErr := errors.New("database down")
+	store := basicauth.StoreFunc(func(_ context.Context, _ string) (string, bool, error) {
+		return "", false, storeErr
+	})
+	auth, err := basicauth.New(store, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = auth.Authenticate(context.Background(), "alice", "password")
+	if !errors.Is(err, storeErr) {
+		t.Errorf("Authenticate() error = %v, want %v", err, storeErr)
+	}
+}
+
+func TestBasicAuthContextDone(t *testing.T) {
+	store := basicauth.MapStore{"alice": mustHashLowCost(t, "alice-password")}
+	auth, err := basicauth.New(store, &basicauth.Options{MaxConcurrent: 1})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := auth.Authenticate(ctx, "alice", "alice-password"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Authenticate() error = %v, want %v", err, context.Canceled)
+	}
+
+	// A request whose context is done before verification gets a 503
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	req.SetBasicAuth("alice", "alice-password")
+	rec := httptest.NewRecorder()
+	auth.Middleware(http.NotFoundHandler()).ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	if _, err := basicauth.New(store, &basicauth.Options{MaxConcurrent: -1}); !errors.Is(err, basicauth.ErrInvalidConcurrent) {
+		t.Errorf("New() with negative MaxConcurrent error = %v, want %v", err, basicauth.ErrInvalidConcurrent)
+	}
+}
+
+func TestParseHtpasswd(t *testing.T) {
+	hash := mustHashLowCost(t, "secret")
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+		users   int
+	}{
+		{name: "Valid", content: "# comment\n\nalice:" + hash + "\nbob:" + hash + "\n", users: 2},
+		{name: "Missing separator", content: "alice\n", wantErr: basicauth.ErrInvalidHtpasswd},
+		{name: "Bcrypt hash", content: "alice:$2y$10$abcdefghijklmnopqrstuu\n", wantErr: basicauth.ErrUnsupportedHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := basicauth.ParseHtpasswd(strings.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseHtpasswd() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(users) != tt.users {
+				t.Errorf("ParseHtpasswd() users = %d, want %d", len(users), tt.users)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), ".htpasswd")
+	if err := os.WriteFile(path, []byte("alice:"+hash+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := basicauth.LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("LoadHtpasswd() error = %v", err)
+	}
+	if got, ok, _ := file.LookupHash(context.Background(), "alice"); !ok || got != hash {
+		t.Errorf("LookupHash() = %q, %v, want stored hash", got, ok)
+	}
+}
+
+func TestBasicAuthVerifiedCache(t *testing.T) {
+	oldHash := mustHashLowCost(t, "old-password")
+	newHash := mustHashLowCost(t, "new-password")
+	current := oldHash
+	store := basicauth.StoreFunc(func(_ context.Context, _ string) (string, bool, error) {
+		return current, true, nil
+	})
+
+	auth, err := basicauth.New(store, &basicauth.Options{CacheTTL: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if ok, err := auth.Authenticate(context.Background(), "alice", "old-password"); err != nil || !ok {
+		t.Fatalf("Authenticate() = %v, %v, want true", ok, err)
+	}
+
+	// Cache hits skip Argon2id and don't wait for a slot, so they succeed
+	// even with a context that is already done
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	if ok, err := auth.Authenticate(done, "alice", "old-password"); err != nil || !ok {
+		t.Errorf("Authenticate() cached = %v, %v, want true", ok, err)
+	}
+
+	// A wrong password is never served from the cache
+	if _, err := auth.Authenticate(done, "alice", "other-password"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Authenticate() uncached error = %v, want %v", err, context.Canceled)
+	}
+
+	// Changing the stored hash invalidates the cached credential
+	current = newHash
+	if ok, err := auth.Authenticate(context.Background(), "alice", "old-password"); err != nil || ok {
+		t.Errorf("Authenticate() after hash change = %v, %v, want false", ok, err)
+	}
+
+	// Entries expire after the TTL
+	current = oldHash
+	time.Sleep(250 * time.Millisecond)
+	if _, err := auth.Authenticate(done, "alice", "old-password"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Authenticate() after TTL error = %v, want %v", err, context.Canceled)
+	}
+
+	if _, err := basicauth.New(store, &basicauth.Options{CacheTTL: -time.Second}); !errors.Is(err, basicauth.ErrInvalidCache) {
+		t.Errorf("New() with negative CacheTTL error = %v, want %v", err, basicauth.ErrInvalidCache)
+	}
+}
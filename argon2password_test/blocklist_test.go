@@ -0,0 +1,144 @@
+package argon2password_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+	"gopkg.hlmpn.dev/pkg/argon2password/blocklist"
+)
+
+func TestBlocklistCommon(t *testing.T) {
+	f, err := os.Open("../common_passwords.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	common := blocklist.Common()
+	words := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		words++
+		if word := scanner.Text(); !common.Contains(word) {
+			t.Errorf("Common().Contains(%q) = false", word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if common.Len() != words {
+		t.Errorf("Common().Len() = %d, want %d, regenerate common.bf with go generate", common.Len(), words)
+	}
+
+	// Compared case-insensitively after NFKC normalization
+	for _, password := range []string{"PASSWORD", "StarWars", "ｐａｓｓｗｏｒｄ"} {
+		if !common.Contains(password) {
+			t.Errorf("Common().Contains(%q) = false", password)
+		}
+	}
+	if common.Contains("correct horse battery staple") {
+		t.Error("Common().Contains() of an uncommon passphrase = true")
+	}
+}
+
+func TestBlocklistFalsePositiveRate(t *testing.T) {
+	// The embedded filter, at the size and rate of common_passwords.txt
+	const probes, rate = 1000000, blocklist.CommonFalsePositiveRate
+	common := blocklist.Common()
+	falsePositives := 0
+	for i := range probes {
+		if common.Contains(fmt.Sprintf("probe-%d", i)) {
+			falsePositives++
+		}
+	}
+	// 100 expected, allow for variance
+	if falsePositives > 2*probes*rate {
+		t.Errorf("%d false positives in %d probes of %d words, want about %d", falsePositives, probes, common.Len(), int(probes*rate))
+	}
+
+	// A filter built for the same rate with the same number of words
+	f, err := blocklist.New(common.Len(), rate)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for i := range common.Len() {
+		f.Add(fmt.Sprintf("word-%d", i))
+	}
+	falsePositives = 0
+	for i := range probes {
+		if f.Contains(fmt.Sprintf("probe-%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 2*probes*rate {
+		t.Errorf("%d false positives in %d probes of New(%d), want about %d", falsePositives, probes, common.Len(), int(probes*rate))
+	}
+}
+
+func TestBlocklistFromWords(t *testing.T) {
+	f, err := blocklist.FromWords(strings.NewReader("acme2025\r\n\nTulips and moss\n  spaced  \n"), 0.0001)
+	if err != nil {
+		t.Fatalf("FromWords() error = %v", err)
+	}
+	if f.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", f.Len())
+	}
+	for _, word := range []string{"acme2025", "tulips and moss", "  spaced  "} {
+		if !f.Contains(word) {
+			t.Errorf("Contains(%q) = false", word)
+		}
+	}
+	if f.Contains("spaced") {
+		t.Error(`Contains("spaced") = true, want lines used as is`)
+	}
+
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := blocklist.Load(data)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, f) {
+		t.Error("Load(MarshalBinary()) differs from the filter")
+	}
+
+	for _, invalid := range [][]byte{nil, data[:20], data[:len(data)-1], append([]byte("A2PWBLM0"), data[8:]...)} {
+		if _, err := blocklist.Load(invalid); !errors.Is(err, blocklist.ErrInvalidFilter) {
+			t.Errorf("Load() of %d invalid bytes error = %v, want %v", len(invalid), err, blocklist.ErrInvalidFilter)
+		}
+	}
+	for _, rate := range []float64{0, 1, -0.5} {
+		if _, err := blocklist.New(10, rate); !errors.Is(err, blocklist.ErrInvalidParams) {
+			t.Errorf("New(10, %v) error = %v, want %v", rate, err, blocklist.ErrInvalidParams)
+		}
+	}
+	if _, err := blocklist.New(-1, 0.01); !errors.Is(err, blocklist.ErrInvalidParams) {
+		t.Errorf("New(-1, 0.01) error = %v, want %v", err, blocklist.ErrInvalidParams)
+	}
+}
+
+func TestBlocklistWithNISTPolicy(t *testing.T) {
+	custom, err := blocklist.FromWords(strings.NewReader("tulips and moss\n"), 0.0001)
+	if err != nil {
+		t.Fatalf("FromWords() error = %v", err)
+	}
+	policy := argon2password.NISTPolicy{Checkers: []argon2password.PasswordChecker{blocklist.Common(), custom}}
+	for _, password := range []string{"starwars", "Tulips and Moss"} {
+		violations, err := policy.Check(context.Background(), password)
+		if err != nil || !reflect.DeepEqual(violationCodes(violations), []argon2password.ViolationCode{argon2password.ViolationBlocklisted}) {
+			t.Errorf("Check(%q) = %v, %v, want blocklisted", password, violations, err)
+		}
+	}
+	if violations, err := policy.Check(context.Background(), "correct horse battery staple"); err != nil || violations != nil {
+		t.Errorf("Check() = %v, %v, want nil, nil", violations, err)
+	}
+}
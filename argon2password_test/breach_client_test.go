@@ -0,0 +1,212 @@
+package argon2password_test
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // the Pwned Passwords API is keyed by SHA-1
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+	"gopkg.hlmpn.dev/pkg/argon2password/breach"
+)
+
+// rangeServer is a fake range API serving the passwords of breachedPasswords
+type rangeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func newRangeServer(t *testing.T) *rangeServer {
+	t.Helper()
+	ranges := make(map[string][]string)
+	for i, password := range breachedPasswords {
+		sum := sha1.Sum([]byte(password)) //nolint:gosec // test data
+		h := strings.ToUpper(hex.EncodeToString(sum[:]))
+		ranges[h[:5]] = append(ranges[h[:5]], fmt.Sprintf("%s:%d", h[5:], i+1))
+	}
+	s := &rangeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+
+		prefix, ok := strings.CutPrefix(r.URL.Path, "/range/")
+		if !ok || len(prefix) != 5 {
+			http.NotFound(w, r)
+			return
+		}
+		lines := append([]string{"0000000000000000000000000000000000A:3"}, ranges[prefix]...)
+		if r.Header.Get("Add-Padding") == "true" {
+			lines = append(lines, "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:0", strings.ToLower(lines[len(lines)-1][:35])+":0")
+		}
+		fmt.Fprint(w, strings.Join(lines, "\r\n"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *rangeServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+func TestBreachClient(t *testing.T) {
+	server := newRangeServer(t)
+	client, err := breach.NewClient(&breach.ClientOptions{BaseURL: server.URL + "/", Padding: true})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for i, password := range breachedPasswords[:20] {
+		count, found, err := client.LookupPassword(context.Background(), password)
+		if err != nil || !found || count != uint32(i+1) {
+			t.Fatalf("LookupPassword(%q) = %d, %v, %v, want %d, true, nil", password, count, found, err, i+1)
+		}
+	}
+	if _, found, err := client.LookupPassword(context.Background(), "a passphrase nobody used"); err != nil || found {
+		t.Errorf("LookupPassword() = %v, %v, want false, nil", found, err)
+	}
+
+	violations, err := client.Check(context.Background(), "tulipsandmoss")
+	if err != nil || !reflect.DeepEqual(violationCodes(violations), []argon2password.ViolationCode{breach.ViolationBreached}) || violations[0].Actual != 2 {
+		t.Errorf("Check() = %+v, %v, want breached 2 times", violations, err)
+	}
+
+	// Only the 5 character prefix is ever sent
+	for _, password := range breachedPasswords[:20] {
+		sum := sha1.Sum([]byte(password)) //nolint:gosec // test data
+		full := strings.ToUpper(hex.EncodeToString(sum[:]))
+		for i, r := range server.requests {
+			dump := fmt.Sprintf("%s %v %s", r.URL.String(), r.Header, server.bodies[i])
+			if strings.Contains(strings.ToUpper(dump), full[5:15]) || strings.Contains(dump, password) {
+				t.Fatalf("request %q leaks more than the prefix of %q", dump, password)
+			}
+		}
+	}
+	for i, r := range server.requests {
+		if r.Method != http.MethodGet || len(r.URL.Path) != len("/range/")+5 || r.URL.RawQuery != "" || server.bodies[i] != "" {
+			t.Errorf("request = %s %s?%s with body %q, want GET /range/<prefix>", r.Method, r.URL.Path, r.URL.RawQuery, server.bodies[i])
+		}
+		if r.Header.Get("User-Agent") != breach.DefaultUserAgent || r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("request headers = %v, want the default User-Agent and Add-Padding", r.Header)
+		}
+	}
+}
+
+func TestBreachClientPadding(t *testing.T) {
+	server := newRangeServer(t)
+	client, err := breach.NewClient(&breach.ClientOptions{BaseURL: server.URL, Padding: true})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	// Padding entries have a count of 0 and aren't breaches
+	var hash [sha1.Size]byte
+	hex.Decode(hash[:], []byte(strings.Repeat("F", 40))) //nolint:errcheck // valid hex
+	if _, found, err := client.Lookup(context.Background(), hash); err != nil || found {
+		t.Errorf("Lookup() of a padding entry = %v, %v, want false, nil", found, err)
+	}
+}
+
+func TestBreachClientCache(t *testing.T) {
+	server := newRangeServer(t)
+	uncached, err := breach.NewClient(&breach.ClientOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	for range 2 {
+		if _, _, err := uncached.LookupPassword(context.Background(), "tulipsandmoss"); err != nil {
+			t.Fatalf("LookupPassword() error = %v", err)
+		}
+	}
+	if n := server.requestCount(); n != 2 {
+		t.Errorf("uncached client sent %d requests, want 2", n)
+	}
+
+	cached, err := breach.NewClient(&breach.ClientOptions{BaseURL: server.URL, CacheTTL: time.Hour, CacheSize: 1})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	for range 3 {
+		if _, found, err := cached.LookupPassword(context.Background(), "tulipsandmoss"); err != nil || !found {
+			t.Fatalf("LookupPassword() = %v, %v, want true, nil", found, err)
+		}
+	}
+	if n := server.requestCount(); n != 3 {
+		t.Errorf("cached client sent %d requests, want 1", n-2)
+	}
+	// CacheSize 1 evicts the first prefix
+	if _, _, err := cached.LookupPassword(context.Background(), "correct horse battery staple"); err != nil {
+		t.Fatalf("LookupPassword() error = %v", err)
+	}
+	if _, _, err := cached.LookupPassword(context.Background(), "tulipsandmoss"); err != nil {
+		t.Fatalf("LookupPassword() error = %v", err)
+	}
+	if n := server.requestCount(); n != 5 {
+		t.Errorf("cached client sent %d requests, want 3", n-2)
+	}
+
+	if _, err := breach.NewClient(&breach.ClientOptions{CacheTTL: -time.Second}); !errors.Is(err, breach.ErrInvalidCache) {
+		t.Errorf("NewClient() with a negative CacheTTL error = %v, want %v", err, breach.ErrInvalidCache)
+	}
+}
+
+func TestBreachClientErrors(t *testing.T) {
+	status := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer status.Close()
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "<html>not a range</html>")
+	}))
+	defer invalid.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"status", status.URL, breach.ErrRangeStatus},
+		{"invalid body", invalid.URL, breach.ErrInvalidRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := breach.NewClient(&breach.ClientOptions{BaseURL: tt.url})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if _, err := client.Check(context.Background(), "tulipsandmoss"); !errors.Is(err, tt.want) {
+				t.Errorf("Check() error = %v, want %v", err, tt.want)
+			}
+			// A failing checker fails the policy instead of letting the password through
+			policy := argon2password.NISTPolicy{Checkers: []argon2password.PasswordChecker{client}}
+			if _, err := policy.Check(context.Background(), "tulipsandmoss"); !errors.Is(err, tt.want) {
+				t.Errorf("NISTPolicy.Check() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	server := newRangeServer(t)
+	client, err := breach.NewClient(&breach.ClientOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Check(ctx, "tulipsandmoss"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Check() with a canceled context error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,209 @@
+package argon2password_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // the Pwned Passwords list is keyed by SHA-1
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+	"gopkg.hlmpn.dev/pkg/argon2password/breach"
+)
+
+// breachedPasswords are written to the test dump, password i is seen i+1 times
+var breachedPasswords = func() []string {
+	passwords := []string{"correct horse battery staple", "tulipsandmoss", "hunter2hunter2"}
+	for i := range 500 {
+		passwords = append(passwords, fmt.Sprintf("breached-%d", i))
+	}
+	return passwords
+}()
+
+// writeBreachDump writes a sorted "HASH:COUNT" dump with "\r\n" line endings like the downloader
+func writeBreachDump(t *testing.T) string {
+	t.Helper()
+	lines := make([]string, 0, len(breachedPasswords))
+	for i, password := range breachedPasswords {
+		sum := sha1.Sum([]byte(password)) //nolint:gosec // test data
+		lines = append(lines, fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(sum[:])), i+1))
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func buildBreachIndex(t *testing.T, dump string, minCount uint32) string {
+	t.Helper()
+	in, err := os.Open(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	var buf bytes.Buffer
+	if _, err := breach.BuildIndex(&buf, in, minCount); err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords.idx")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreachList(t *testing.T) {
+	dump := writeBreachDump(t)
+	for name, path := range map[string]string{"text": dump, "index": buildBreachIndex(t, dump, 0)} {
+		t.Run(name, func(t *testing.T) {
+			list, err := breach.Open(path)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer list.Close()
+
+			for i, password := range breachedPasswords {
+				count, found, err := list.LookupPassword(password)
+				if err != nil || !found || count != uint32(i+1) {
+					t.Fatalf("LookupPassword(%q) = %d, %v, %v, want %d, true, nil", password, count, found, err, i+1)
+				}
+			}
+			for _, password := range []string{"not breached", "", "breached-500", "breached-"} {
+				if _, found, err := list.LookupPassword(password); err != nil || found {
+					t.Errorf("LookupPassword(%q) = %v, %v, want false, nil", password, found, err)
+				}
+			}
+			// Before the first and after the last hash
+			for _, hash := range [][sha1.Size]byte{{}, {0: 0xff, 19: 0xff}} {
+				if _, found, err := list.Lookup(hash); err != nil || found {
+					t.Errorf("Lookup(%x) = %v, %v, want false, nil", hash, found, err)
+				}
+			}
+
+			violations, err := list.Check(context.Background(), "tulipsandmoss")
+			want := []argon2password.Violation{{Code: breach.ViolationBreached, Message: "Password appeared in a data breach, choose a different one", Required: 1, Actual: 2}}
+			if err != nil || !reflect.DeepEqual(violations, want) {
+				t.Errorf("Check() = %+v, %v, want %+v", violations, err, want)
+			}
+			list.MinCount = 3
+			if violations, err := list.Check(context.Background(), "tulipsandmoss"); err != nil || violations != nil {
+				t.Errorf("Check() under MinCount = %+v, %v, want nil, nil", violations, err)
+			}
+
+			if err := list.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+			if _, _, err := list.LookupPassword("tulipsandmoss"); !errors.Is(err, breach.ErrListClosed) {
+				t.Errorf("LookupPassword() after Close error = %v, want %v", err, breach.ErrListClosed)
+			}
+		})
+	}
+}
+
+func TestBreachListWithNISTPolicy(t *testing.T) {
+	list, err := breach.Open(buildBreachIndex(t, writeBreachDump(t), 0))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer list.Close()
+	policy := argon2password.NISTPolicy{Checkers: []argon2password.PasswordChecker{list}}
+
+	violations, err := policy.Check(context.Background(), "correct horse battery staple")
+	if err != nil || !reflect.DeepEqual(violationCodes(violations), []argon2password.ViolationCode{breach.ViolationBreached}) {
+		t.Errorf("Check() = %v, %v, want breached", violations, err)
+	}
+	if violations, err := policy.Check(context.Background(), "a passphrase nobody used"); err != nil || violations != nil {
+		t.Errorf("Check() = %v, %v, want nil, nil", violations, err)
+	}
+}
+
+func TestBreachBuildIndex(t *testing.T) {
+	dump := writeBreachDump(t)
+	list, err := breach.Open(buildBreachIndex(t, dump, 400))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer list.Close()
+	if _, found, _ := list.LookupPassword("breached-100"); found {
+		t.Error("LookupPassword() found a password under -min-count")
+	}
+	if count, found, _ := list.LookupPassword("breached-499"); !found || count != 503 {
+		t.Errorf("LookupPassword() = %d, %v, want 503, true", count, found)
+	}
+
+	sum := sha1.Sum([]byte("x")) //nolint:gosec // test data
+	hash := hex.EncodeToString(sum[:])
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"unsorted", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1\n" + hash + ":1\n", breach.ErrUnsorted},
+		{"duplicate", hash + ":1\n" + hash + ":2\n", breach.ErrUnsorted},
+		{"short hash", "ABCDEF:1\n", breach.ErrInvalidList},
+		{"bad count", hash + ":many\n", breach.ErrInvalidList},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := breach.BuildIndex(&bytes.Buffer{}, strings.NewReader(tt.input), 0); !errors.Is(err, tt.want) {
+				t.Errorf("BuildIndex() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Lowercase hashes without counts are accepted and counted once
+	var buf bytes.Buffer
+	if n, err := breach.BuildIndex(&buf, strings.NewReader(hash+"\n\n"), 0); err != nil || n != 1 {
+		t.Errorf("BuildIndex() = %d, %v, want 1, nil", n, err)
+	}
+}
+
+func TestBreachOpenInvalid(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.txt")
+	truncated := filepath.Join(dir, "truncated.idx")
+	garbage := filepath.Join(dir, "garbage.txt")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(truncated, []byte("A2PWBRI1 not a record"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(garbage, []byte("this is not a password dump\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := breach.Open(empty)
+	if err != nil {
+		t.Fatalf("Open() of an empty file error = %v", err)
+	}
+	if _, found, err := list.LookupPassword("password"); err != nil || found {
+		t.Errorf("LookupPassword() in an empty list = %v, %v, want false, nil", found, err)
+	}
+	list.Close()
+
+	if _, err := breach.Open(truncated); !errors.Is(err, breach.ErrInvalidList) {
+		t.Errorf("Open() of a truncated index error = %v, want %v", err, breach.ErrInvalidList)
+	}
+	if _, err := breach.Open(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Open() of a missing file error = %v, want %v", err, os.ErrNotExist)
+	}
+
+	list, err = breach.Open(garbage)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer list.Close()
+	if _, _, err := list.LookupPassword("password"); !errors.Is(err, breach.ErrInvalidList) {
+		t.Errorf("LookupPassword() in an invalid dump error = %v, want %v", err, breach.ErrInvalidList)
+	}
+}
@@ -0,0 +1,67 @@
+package argon2password_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestCalibrate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping calibration benchmark in short mode")
+	}
+
+	const maxMemory = 16 * argon2password.MiB
+	config, err := argon2password.Calibrate(300*time.Millisecond, maxMemory)
+	if err != nil {
+		t.Fatalf("Calibrate() error = %v", err)
+	}
+
+	if config.Memory > maxMemory || config.Memory%argon2password.MiB != 0 {
+		t.Errorf("Calibrate() memory = %d, want whole MiB up to %d", config.Memory, maxMemory)
+	}
+	if config.Iterations < 1 || config.Iterations > argon2password.ArgonMaxIterations {
+		t.Errorf("Calibrate() iterations = %d, want 1-%d", config.Iterations, argon2password.ArgonMaxIterations)
+	}
+	if config.Parallelism == 0 {
+		t.Errorf("Calibrate() parallelism = 0")
+	}
+
+	// The calibrated config must be usable as is
+	hash, err := argon2password.HashWithConfig("calibrated", config)
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+	match, err := argon2password.ComparePW("calibrated", hash)
+	if err != nil || !match {
+		t.Errorf("ComparePW() = %v, %v, want true", match, err)
+	}
+}
+
+func TestCalibrateErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    time.Duration
+		maxMemory argon2password.MemorySize
+		wantErr   error
+	}{
+		{name: "Zero target", target: 0, maxMemory: 0, wantErr: argon2password.ErrCalibrationTarget},
+		{name: "Negative target", target: -time.Second, maxMemory: 0, wantErr: argon2password.ErrCalibrationTarget},
+		{name: "Max memory below minimum", target: time.Second, maxMemory: argon2password.MiB, wantErr: argon2password.ErrCalibrationMaxMemory},
+		{name: "Unreachable target", target: time.Nanosecond, maxMemory: 8 * argon2password.MiB, wantErr: argon2password.ErrCalibrationTooSlow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := argon2password.Calibrate(tt.target, tt.maxMemory)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Calibrate() error = %v, want %v", err, tt.wantErr)
+			}
+			if config != nil {
+				t.Errorf("Calibrate() config = %+v, want nil", config)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package argon2password_test
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"testing/fstest"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+	"gopkg.hlmpn.dev/pkg/argon2password/internal/cgroup"
+)
+
+func file(data string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(data)} }
+
+func TestCPULimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		fsys   fstest.MapFS
+		want   int
+		wantOK bool
+	}{
+		{
+			name:   "No cgroup",
+			fsys:   fstest.MapFS{},
+			wantOK: false,
+		},
+		{
+			name: "v2 container with 1 CPU",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":      file("0::/\n"),
+				"sys/fs/cgroup/cpu.max": file("100000 100000\n"),
+			},
+			want:   1,
+			wantOK: true,
+		},
+		{
+			name: "v2 fractional quota rounds up",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":      file("0::/\n"),
+				"sys/fs/cgroup/cpu.max": file("150000 100000\n"),
+			},
+			want:   2,
+			wantOK: true,
+		},
+		{
+			name: "v2 unlimited",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":      file("0::/\n"),
+				"sys/fs/cgroup/cpu.max": file("max 100000\n"),
+			},
+			wantOK: false,
+		},
+		{
+			name: "v2 nested cgroup limited by its parent",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                        file("0::/kubepods/pod1/app\n"),
+				"sys/fs/cgroup/kubepods/pod1/app/cpu.max": file("max 100000\n"),
+				"sys/fs/cgroup/kubepods/pod1/cpu.max":     file("200000 100000\n"),
+				"sys/fs/cgroup/kubepods/cpu.max":          file("800000 100000\n"),
+			},
+			want:   2,
+			wantOK: true,
+		},
+		{
+			name: "v1 container with 2 CPUs",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                            file("12:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n"),
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  file("200000\n"),
+				"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": file("100000\n"),
+			},
+			want:   2,
+			wantOK: true,
+		},
+		{
+			name: "v1 cgroup path under the mount",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup": file("4:cpu,cpuacct:/docker/abc\n"),
+				"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_quota_us":  file("50000\n"),
+				"sys/fs/cgroup/cpu,cpuacct/docker/abc/cpu.cfs_period_us": file("100000\n"),
+			},
+			want:   1,
+			wantOK: true,
+		},
+		{
+			name: "v1 unlimited",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                    file("3:cpu:/\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":  file("-1\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us": file("100000\n"),
+			},
+			wantOK: false,
+		},
+		{
+			name: "v2 without cpu.max",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":         file("0::/\n"),
+				"sys/fs/cgroup/memory.max": file("max\n"),
+			},
+			wantOK: false,
+		},
+		{
+			name: "v2 quota under one CPU",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":      file("0::/\n"),
+				"sys/fs/cgroup/cpu.max": file("25000 100000\n"),
+			},
+			want:   1,
+			wantOK: true,
+		},
+		{
+			name: "Hybrid v1 quota without cpu.max",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                    file("0::/init.scope\n3:cpu:/\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":  file("300000\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us": file("100000\n"),
+			},
+			want:   3,
+			wantOK: true,
+		},
+		{
+			name: "v1 fractional quota rounds up",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                    file("3:cpu:/\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":  file("250000\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us": file("100000\n"),
+			},
+			want:   3,
+			wantOK: true,
+		},
+		{
+			name: "v1 missing period",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                   file("3:cpu:/\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us": file("200000\n"),
+			},
+			wantOK: false,
+		},
+		{
+			name: "v1 zero period",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                    file("3:cpu:/\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_quota_us":  file("200000\n"),
+				"sys/fs/cgroup/cpu/cpu.cfs_period_us": file("0\n"),
+			},
+			wantOK: false,
+		},
+		{
+			name: "Malformed cpu.max",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":      file("0::/\n"),
+				"sys/fs/cgroup/cpu.max": file("lots\n"),
+			},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cgroup.CPULimit(tt.fsys)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("CPULimit() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAvailableCPUs(t *testing.T) {
+	twoCPUs := fstest.MapFS{
+		"proc/self/cgroup":      file("0::/\n"),
+		"sys/fs/cgroup/cpu.max": file("200000 100000\n"),
+	}
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		maxProcs int
+		want     int
+	}{
+		{name: "No cgroup", fsys: fstest.MapFS{}, maxProcs: 8, want: 8},
+		{name: "Quota under GOMAXPROCS", fsys: twoCPUs, maxProcs: 64, want: 2},
+		{name: "GOMAXPROCS under quota", fsys: twoCPUs, maxProcs: 1, want: 1},
+		{
+			name: "Fractional quota",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":      file("0::/\n"),
+				"sys/fs/cgroup/cpu.max": file("50000 100000\n"),
+			},
+			maxProcs: 16,
+			want:     1,
+		},
+		{
+			name: "Unlimited",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":      file("0::/\n"),
+				"sys/fs/cgroup/cpu.max": file("max 100000\n"),
+			},
+			maxProcs: 4,
+			want:     4,
+		},
+		{name: "At least one", fsys: fstest.MapFS{}, maxProcs: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cgroup.AvailableCPUs(tt.fsys, tt.maxProcs); got != tt.want {
+				t.Errorf("AvailableCPUs(%d) = %d, want %d", tt.maxProcs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		fsys   fstest.MapFS
+		want   uint64
+		wantOK bool
+	}{
+		{
+			name:   "No cgroup",
+			fsys:   fstest.MapFS{},
+			wantOK: false,
+		},
+		{
+			name: "v2 sidecar with 256 MiB",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":         file("0::/\n"),
+				"sys/fs/cgroup/memory.max": file("268435456\n"),
+			},
+			want:   256 << 20,
+			wantOK: true,
+		},
+		{
+			name: "v2 unlimited",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":         file("0::/\n"),
+				"sys/fs/cgroup/memory.max": file("max\n"),
+			},
+			wantOK: false,
+		},
+		{
+			name: "v2 nested cgroup limited by its parent",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                           file("0::/kubepods/pod1/app\n"),
+				"sys/fs/cgroup/kubepods/pod1/app/memory.max": file("max\n"),
+				"sys/fs/cgroup/kubepods/pod1/memory.max":     file("536870912\n"),
+				"sys/fs/cgroup/kubepods/memory.max":          file("1073741824\n"),
+			},
+			want:   512 << 20,
+			wantOK: true,
+		},
+		{
+			name: "v1 container with 128 MiB",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                           file("4:cpu,cpuacct:/docker/abc\n12:memory:/docker/abc\n"),
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": file("134217728\n"),
+			},
+			want:   128 << 20,
+			wantOK: true,
+		},
+		{
+			name: "v1 unlimited",
+			fsys: fstest.MapFS{
+				"proc/self/cgroup":                           file("12:memory:/\n"),
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": file("9223372036854771712\n"),
+			},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cgroup.MemoryLimit(tt.fsys)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("MemoryLimit() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// memoryLimitedChild marks the test binary re-run by TestMemoryLimitedDefaults
+const memoryLimitedChild = "ARGON2PASSWORD_TEST_MEMORY_LIMITED"
+
+func TestMemoryLimitedDefaults(t *testing.T) {
+	if os.Getenv(memoryLimitedChild) == "1" {
+		// The limit is read at init, so this runs in a child with GOMEMLIMIT=64MiB:
+		// a 32 MiB budget, too small for one 64 MiB hash
+		config := argon2password.DefaultConfig()
+		if config.Memory != 19*argon2password.MiB || config.Iterations != argon2password.ArgonIterations {
+			t.Errorf("DefaultConfig() = %+v, want m=19 MiB,t=%d", config, argon2password.ArgonIterations)
+		}
+		if n := argon2password.MaxConcurrentHashes(); n != 1 {
+			t.Errorf("MaxConcurrentHashes() = %d, want 1", n)
+		}
+
+		t.Cleanup(func() {
+			if err := argon2password.SetDefaultConfig(nil); err != nil {
+				t.Errorf("SetDefaultConfig(nil) error = %v", err)
+			}
+		})
+		if err := argon2password.SetDefaultConfig(argon2password.PresetOWASP7M5T.Config()); err != nil {
+			t.Fatalf("SetDefaultConfig() error = %v", err)
+		}
+		if n := argon2password.MaxConcurrentHashes(); n != 4 {
+			t.Errorf("MaxConcurrentHashes() with 7 MiB = %d, want 4", n)
+		}
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMemoryLimitedDefaults$")
+	cmd.Env = append(os.Environ(), memoryLimitedChild+"=1", "GOMEMLIMIT=64MiB")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("memory limited run failed: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,171 @@
+package argon2password_test
+
+import (
+	"errors"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestMemorySize(t *testing.T) {
+	tests := []struct {
+		size    argon2password.MemorySize
+		wantKiB uint32
+		want    string
+	}{
+		{size: 0, wantKiB: 0, want: "0 KiB"},
+		{size: 512 * argon2password.KiB, wantKiB: 512, want: "512 KiB"},
+		{size: 64 * argon2password.MiB, wantKiB: 65536, want: "64 MiB"},
+		{size: 1536 * argon2password.KiB, wantKiB: 1536, want: "1536 KiB"},
+		{size: 2 * argon2password.GiB, wantKiB: 2097152, want: "2 GiB"},
+		{size: argon2password.ArgonMemory, wantKiB: 65536, want: "64 MiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.size.KiB(); got != tt.wantKiB {
+				t.Errorf("KiB() = %d, want %d", got, tt.wantKiB)
+			}
+			if got := tt.size.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	config, err := argon2password.NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() error = %v", err)
+	}
+	if config.Memory != argon2password.ArgonMemory || config.MaxMemory != argon2password.ArgonMaxMemory {
+		t.Errorf("NewConfig() memory = %s, max %s, want %s, max %s",
+			config.Memory, config.MaxMemory, argon2password.ArgonMemory, argon2password.ArgonMaxMemory)
+	}
+	if config.Iterations != argon2password.ArgonIterations || config.SaltLength != argon2password.ArgonSaltLength ||
+		config.KeyLength != argon2password.ArgonKeyLength || config.Parallelism == 0 {
+		t.Errorf("NewConfig() = %+v, want the defaults", config)
+	}
+
+	config, err = argon2password.NewConfig(
+		argon2password.WithMemory(128*argon2password.MiB),
+		argon2password.WithIterations(4),
+		argon2password.WithParallelism(1),
+		argon2password.WithSaltLength(24),
+		argon2password.WithKeyLength(64),
+		argon2password.WithMaxMemory(256*argon2password.MiB),
+		argon2password.WithMaxIterations(5),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig() with options error = %v", err)
+	}
+	want := argon2password.Config{
+		Memory: 128 * argon2password.MiB, Iterations: 4, Parallelism: 1, SaltLength: 24, KeyLength: 64,
+		MaxMemory: 256 * argon2password.MiB, MaxIterations: 5, MaxPasswordLength: argon2password.ArgonMaxPasswordLength,
+	}
+	if *config != want {
+		t.Errorf("NewConfig() with options = %+v, want %+v", *config, want)
+	}
+
+	config, err = argon2password.NewConfig(argon2password.WithPreset(argon2password.PresetOWASP19M2T), argon2password.WithIterations(3))
+	if err != nil {
+		t.Fatalf("NewConfig() with preset error = %v", err)
+	}
+	if config.Memory != 19*argon2password.MiB || config.Iterations != 3 {
+		t.Errorf("NewConfig() with preset = %+v, want m=19 MiB,t=3", config)
+	}
+
+	// The 4 lanes of the RFC 9106 presets are kept whatever the number of CPUs
+	config, err = argon2password.NewConfig(argon2password.WithPreset(argon2password.PresetRFC9106Second))
+	if err != nil {
+		t.Fatalf("NewConfig() with RFC 9106 preset error = %v", err)
+	}
+	if config.Parallelism != 4 {
+		t.Errorf("NewConfig() with RFC 9106 preset parallelism = %d, want 4", config.Parallelism)
+	}
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []argon2password.Option
+		wantField string
+		wantErr   error
+	}{
+		{
+			name:      "Memory meant as MB",
+			opts:      []argon2password.Option{argon2password.WithMemory(64)},
+			wantField: "Memory",
+			wantErr:   argon2password.ErrConfigMemoryTooSmall,
+		},
+		{
+			name:      "Memory over max",
+			opts:      []argon2password.Option{argon2password.WithMemory(argon2password.ArgonMaxMemory + 1)},
+			wantField: "Memory",
+			wantErr:   argon2password.ErrConfigMemoryExceedsMax,
+		},
+		{
+			name:      "Below OWASP minimum",
+			opts:      []argon2password.Option{argon2password.WithMemory(19 * argon2password.MiB), argon2password.WithIterations(1)},
+			wantField: "Memory",
+			wantErr:   argon2password.ErrConfigBelowOWASPMinimum,
+		},
+		{
+			name:      "Iterations over max",
+			opts:      []argon2password.Option{argon2password.WithIterations(argon2password.ArgonMaxIterations + 1)},
+			wantField: "Iterations",
+			wantErr:   argon2password.ErrConfigIterationsExceedsMax,
+		},
+		{
+			name:      "Short salt",
+			opts:      []argon2password.Option{argon2password.WithSaltLength(8)},
+			wantField: "SaltLength",
+			wantErr:   argon2password.ErrConfigSaltTooShort,
+		},
+		{
+			name:      "Short key",
+			opts:      []argon2password.Option{argon2password.WithKeyLength(16)},
+			wantField: "KeyLength",
+			wantErr:   argon2password.ErrConfigKeyTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := argon2password.NewConfig(tt.opts...)
+			if config != nil {
+				t.Errorf("NewConfig() config = %+v, want nil", config)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewConfig() error = %v, want %v", err, tt.wantErr)
+			}
+			if !argon2password.IsConfigError(err) {
+				t.Errorf("IsConfigError(%v) = false", err)
+			}
+			var configErr *argon2password.ConfigError
+			if !errors.As(err, &configErr) || configErr.Field != tt.wantField {
+				t.Errorf("NewConfig() error field = %+v, want %q", configErr, tt.wantField)
+			}
+		})
+	}
+
+	// Every invalid field is reported
+	_, err := argon2password.NewConfig(argon2password.WithSaltLength(8), argon2password.WithKeyLength(8))
+	if !errors.Is(err, argon2password.ErrConfigSaltTooShort) || !errors.Is(err, argon2password.ErrConfigKeyTooShort) {
+		t.Errorf("NewConfig() error = %v, want both salt and key errors", err)
+	}
+	if argon2password.IsConfigError(errors.New("other")) {
+		t.Error("IsConfigError() = true for an unrelated error")
+	}
+}
+
+func TestHashWithConfigMemoryTooSmall(t *testing.T) {
+	// 64 KiB instead of 64 MiB, hashing must fail rather than produce a weak hash
+	config := &argon2password.Config{Memory: 64, Iterations: 3, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	if hash, err := argon2password.HashWithConfig("x", config); !errors.Is(err, argon2password.ErrConfigMemoryTooSmall) || hash != "" {
+		t.Errorf("HashWithConfig() = %q, %v, want %v", hash, err, argon2password.ErrConfigMemoryTooSmall)
+	}
+	if _, err := argon2password.WrapLegacyHashWithConfig("5f4dcc3b5aa765d61d8327deb882cf99", "", config); !errors.Is(err, argon2password.ErrConfigMemoryTooSmall) {
+		t.Errorf("WrapLegacyHashWithConfig() error = %v, want %v", err, argon2password.ErrConfigMemoryTooSmall)
+	}
+}
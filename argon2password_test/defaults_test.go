@@ -0,0 +1,121 @@
+package argon2password_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestSetDefaultConfig(t *testing.T) {
+	t.Cleanup(func() {
+		if err := argon2password.SetDefaultConfig(nil); err != nil {
+			t.Errorf("SetDefaultConfig(nil) error = %v", err)
+		}
+	})
+
+	builtin := argon2password.DefaultConfig()
+	// The memory is only lowered under a memory limit
+	if builtin.Memory > argon2password.ArgonMemory || builtin.Iterations < argon2password.ArgonIterations ||
+		builtin.MaxMemory != argon2password.ArgonMaxMemory || builtin.Parallelism == 0 {
+		t.Errorf("DefaultConfig() = %+v, want the built-in defaults", builtin)
+	}
+
+	config := argon2password.PresetOWASP7M5T.Config()
+	if err := argon2password.SetDefaultConfig(config); err != nil {
+		t.Fatalf("SetDefaultConfig() error = %v", err)
+	}
+	// The config is copied
+	config.Memory = argon2password.GiB
+	if got := argon2password.DefaultConfig(); got.Memory != 7*argon2password.MiB || got.Iterations != 5 {
+		t.Errorf("DefaultConfig() = %+v, want m=7 MiB,t=5", got)
+	}
+	// So is the result
+	argon2password.DefaultConfig().Memory = argon2password.GiB
+	if got := argon2password.DefaultConfig(); got.Memory != 7*argon2password.MiB {
+		t.Errorf("modifying the result of DefaultConfig() changed the default to %s", got.Memory)
+	}
+
+	hash, err := argon2password.HashPW("password")
+	if err != nil {
+		t.Fatalf("HashPW() error = %v", err)
+	}
+	info, err := argon2password.DecodeHash(hash)
+	if err != nil {
+		t.Fatalf("DecodeHash() error = %v", err)
+	}
+	if info.Memory != 7168 || info.Iterations != 5 || info.Parallelism != 1 {
+		t.Errorf("HashPW() with the new defaults = %+v, want m=7168,t=5,p=1", info)
+	}
+	if match, err := argon2password.ComparePW("password", hash); err != nil || !match {
+		t.Errorf("ComparePW() = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.CompareDummy("password"); err != nil || match {
+		t.Errorf("CompareDummy() = %v, %v, want false, nil", match, err)
+	}
+
+	// Invalid configs are rejected and the defaults are kept
+	err = argon2password.SetDefaultConfig(&argon2password.Config{Memory: 8 * argon2password.MiB, Iterations: 1})
+	if !errors.Is(err, argon2password.ErrConfigBelowOWASPMinimum) {
+		t.Errorf("SetDefaultConfig() below OWASP error = %v, want %v", err, argon2password.ErrConfigBelowOWASPMinimum)
+	}
+	if got := argon2password.DefaultConfig(); got.Memory != 7*argon2password.MiB {
+		t.Errorf("DefaultConfig() after a rejected config = %+v", got)
+	}
+
+	// The limits apply to ComparePW
+	if err := argon2password.SetDefaultConfig(&argon2password.Config{Memory: 19 * argon2password.MiB, Iterations: 2, MaxIterations: 4}); err != nil {
+		t.Fatalf("SetDefaultConfig() error = %v", err)
+	}
+	if _, err := argon2password.ComparePW("password", hash); !errors.Is(err, argon2password.ErrInvalidParams) {
+		t.Errorf("ComparePW() over MaxIterations error = %v, want %v", err, argon2password.ErrInvalidParams)
+	}
+
+	if err := argon2password.SetDefaultConfig(nil); err != nil {
+		t.Fatalf("SetDefaultConfig(nil) error = %v", err)
+	}
+	if got := argon2password.DefaultConfig(); *got != *builtin {
+		t.Errorf("DefaultConfig() after reset = %+v, want %+v", got, builtin)
+	}
+}
+
+func TestSetDefaultConfigConcurrent(t *testing.T) {
+	t.Cleanup(func() {
+		if err := argon2password.SetDefaultConfig(nil); err != nil {
+			t.Errorf("SetDefaultConfig(nil) error = %v", err)
+		}
+	})
+
+	configs := []*argon2password.Config{
+		argon2password.PresetOWASP7M5T.Config(),
+		argon2password.PresetOWASP9M4T.Config(),
+	}
+	hash := mustHashLowCost(t, "password")
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				if err := argon2password.SetDefaultConfig(configs[(i+j)%len(configs)]); err != nil {
+					t.Errorf("SetDefaultConfig() error = %v", err)
+				}
+				if got := argon2password.DefaultConfig(); got.Memory != 7*argon2password.MiB && got.Memory != 9*argon2password.MiB {
+					t.Errorf("DefaultConfig() = %+v, want one of the set configs", got)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if match, err := argon2password.ComparePW("password", hash); err != nil || !match {
+				t.Errorf("ComparePW() = %v, %v, want true, nil", match, err)
+			}
+			if match, err := argon2password.CompareDummy("password"); err != nil || match {
+				t.Errorf("CompareDummy() = %v, %v, want false, nil", match, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,154 @@
+package argon2password_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    argon2password.MemorySize
+		wantErr error
+	}{
+		{input: "65536", want: 64 * argon2password.MiB},
+		{input: "64MiB", want: 64 * argon2password.MiB},
+		{input: " 64 MiB ", want: 64 * argon2password.MiB},
+		{input: "64mib", want: 64 * argon2password.MiB},
+		{input: "19456KiB", want: 19 * argon2password.MiB},
+		{input: "2GiB", want: 2 * argon2password.GiB},
+		{input: "64MB", wantErr: argon2password.ErrMemorySizeUnit},
+		{input: "64M", wantErr: argon2password.ErrMemorySizeUnit},
+		{input: "64 bytes", wantErr: argon2password.ErrMemorySizeUnit},
+		{input: "", wantErr: argon2password.ErrInvalidMemorySize},
+		{input: "MiB", wantErr: argon2password.ErrInvalidMemorySize},
+		{input: "-1MiB", wantErr: argon2password.ErrInvalidMemorySize},
+		{input: "4096GiB", wantErr: argon2password.ErrInvalidMemorySize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := argon2password.ParseMemorySize(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseMemorySize() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMemorySize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("TEST_ARGON2_MEMORY", "128MiB")
+	t.Setenv("TEST_ARGON2_ITERATIONS", "4")
+	t.Setenv("TEST_ARGON2_PARALLELISM", "1")
+	t.Setenv("TEST_ARGON2_SALT_LENGTH", "")
+
+	config, err := argon2password.ConfigFromEnv("TEST_ARGON2_")
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	if config.Memory != 128*argon2password.MiB || config.Iterations != 4 || config.Parallelism != 1 {
+		t.Errorf("ConfigFromEnv() = %+v, want m=128 MiB,t=4,p=1", config)
+	}
+	if config.SaltLength != argon2password.ArgonSaltLength || config.MaxMemory != argon2password.ArgonMaxMemory {
+		t.Errorf("ConfigFromEnv() = %+v, want the defaults for unset variables", config)
+	}
+
+	// The calibrate -format env output uses KiB
+	t.Setenv("TEST_ARGON2_MEMORY", "19456")
+	t.Setenv("TEST_ARGON2_ITERATIONS", "2")
+	if config, err = argon2password.ConfigFromEnv("TEST_ARGON2_"); err != nil || config.Memory != 19*argon2password.MiB {
+		t.Errorf("ConfigFromEnv() = %+v, %v, want m=19 MiB", config, err)
+	}
+
+	t.Setenv("TEST_ARGON2_PARALLELISM", "256")
+	if _, err := argon2password.ConfigFromEnv("TEST_ARGON2_"); !errors.Is(err, argon2password.ErrConfigEnv) {
+		t.Errorf("ConfigFromEnv() with parallelism 256 error = %v, want %v", err, argon2password.ErrConfigEnv)
+	}
+
+	t.Setenv("TEST_ARGON2_PARALLELISM", "1")
+	t.Setenv("TEST_ARGON2_MEMORY", "64MB")
+	if _, err := argon2password.ConfigFromEnv("TEST_ARGON2_"); !errors.Is(err, argon2password.ErrConfigEnv) || !errors.Is(err, argon2password.ErrMemorySizeUnit) {
+		t.Errorf("ConfigFromEnv() with 64MB error = %v, want %v", err, argon2password.ErrMemorySizeUnit)
+	}
+
+	// Values are validated like NewConfig
+	t.Setenv("TEST_ARGON2_MEMORY", "8MiB")
+	t.Setenv("TEST_ARGON2_ITERATIONS", "1")
+	if _, err := argon2password.ConfigFromEnv("TEST_ARGON2_"); !errors.Is(err, argon2password.ErrConfigBelowOWASPMinimum) {
+		t.Errorf("ConfigFromEnv() below OWASP error = %v, want %v", err, argon2password.ErrConfigBelowOWASPMinimum)
+	}
+}
+
+func TestConfigUnmarshalJSON(t *testing.T) {
+	var config argon2password.Config
+	if err := json.Unmarshal([]byte(`{"memory": "128MiB", "iterations": 4, "parallelism": 1, "max_memory": 1048576}`), &config); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	want := argon2password.Config{
+		Memory:            128 * argon2password.MiB,
+		Iterations:        4,
+		Parallelism:       1,
+		SaltLength:        argon2password.ArgonSaltLength,
+		KeyLength:         argon2password.ArgonKeyLength,
+		MaxMemory:         argon2password.GiB,
+		MaxIterations:     argon2password.ArgonMaxIterations,
+		MaxPasswordLength: argon2password.ArgonMaxPasswordLength,
+	}
+	if config != want {
+		t.Errorf("json.Unmarshal() = %+v, want %+v", config, want)
+	}
+
+	// Marshaling uses units and round trips
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var again argon2password.Config
+	if err := json.Unmarshal(data, &again); err != nil || again != config {
+		t.Errorf("json round trip of %s = %+v, %v", data, again, err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "Decimal unit", input: `{"memory": "64MB"}`, wantErr: argon2password.ErrMemorySizeUnit},
+		{name: "Below OWASP minimum", input: `{"memory": "8MiB", "iterations": 1}`, wantErr: argon2password.ErrConfigBelowOWASPMinimum},
+		{name: "Over max", input: `{"memory": "1GiB"}`, wantErr: argon2password.ErrConfigMemoryExceedsMax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config argon2password.Config
+			if err := json.Unmarshal([]byte(tt.input), &config); !errors.Is(err, tt.wantErr) {
+				t.Errorf("json.Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigUnmarshalYAML(t *testing.T) {
+	// A YAML decoder calls UnmarshalYAML with a function decoding into the given value,
+	// JSON stands in for it to avoid the dependency
+	decode := func(doc string) func(any) error {
+		return func(v any) error { return json.Unmarshal([]byte(doc), v) }
+	}
+
+	var config argon2password.Config
+	if err := config.UnmarshalYAML(decode(`{"memory": "46MiB", "iterations": 1}`)); err != nil {
+		t.Fatalf("UnmarshalYAML() error = %v", err)
+	}
+	if config.Memory != 46*argon2password.MiB || config.Iterations != 1 || config.KeyLength != argon2password.ArgonKeyLength {
+		t.Errorf("UnmarshalYAML() = %+v, want m=46 MiB,t=1 with defaults", config)
+	}
+
+	if err := config.UnmarshalYAML(decode(`{"salt_length": 8}`)); !errors.Is(err, argon2password.ErrConfigSaltTooShort) {
+		t.Errorf("UnmarshalYAML() error = %v, want %v", err, argon2password.ErrConfigSaltTooShort)
+	}
+}
@@ -0,0 +1,113 @@
+package argon2password_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestNISTPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   argon2password.NISTPolicy
+		password string
+		want     []argon2password.ViolationCode
+	}{
+		{"lowercase passphrase", argon2password.NISTPolicy{}, "correct horse battery staple", nil},
+		{"no composition rules", argon2password.NISTPolicy{}, "tulipsandmoss", nil},
+		{"long passphrase", argon2password.NISTPolicy{}, strings.Repeat("long passphrase ", 8) + "end", nil},
+		{"too short", argon2password.NISTPolicy{}, "Ab1!xyz", []argon2password.ViolationCode{argon2password.ViolationTooShort}},
+		{"code points", argon2password.NISTPolicy{}, "ééééé", []argon2password.ViolationCode{argon2password.ViolationTooShort, argon2password.ViolationRepetitive}},
+		{"single factor", argon2password.NISTPolicy{MinLength: argon2password.NISTMinLengthSingleFactor}, "tulipsandmoss", []argon2password.ViolationCode{argon2password.ViolationTooShort}},
+		{"max length", argon2password.NISTPolicy{MaxLength: 64}, strings.Repeat("x", 30) + strings.Repeat("y", 35), []argon2password.ViolationCode{argon2password.ViolationTooLong}},
+		{"blocklisted", argon2password.NISTPolicy{}, "TrustNo1", []argon2password.ViolationCode{argon2password.ViolationBlocklisted}},
+		{"common_passwords.txt", argon2password.NISTPolicy{}, "Maverick", []argon2password.ViolationCode{argon2password.ViolationBlocklisted}},
+		{"custom blocklist", argon2password.NISTPolicy{Blocklist: []string{"Hunter2Hunter"}}, "hunter2hunter", []argon2password.ViolationCode{argon2password.ViolationBlocklisted}},
+		{"repeated character", argon2password.NISTPolicy{}, "aaaaaaaaaa", []argon2password.ViolationCode{argon2password.ViolationRepetitive}},
+		{"repeated pattern", argon2password.NISTPolicy{}, "hoplaHOPLAhopla", []argon2password.ViolationCode{argon2password.ViolationRepetitive}},
+		{"ascending", argon2password.NISTPolicy{}, "12345678", []argon2password.ViolationCode{argon2password.ViolationSequential}},
+		{"descending", argon2password.NISTPolicy{}, "zyxwvuts", []argon2password.ViolationCode{argon2password.ViolationSequential}},
+		{"two runs", argon2password.NISTPolicy{}, "1234abcd", []argon2password.ViolationCode{argon2password.ViolationSequential}},
+		{"keyboard row", argon2password.NISTPolicy{}, "QWERTYUIOP", []argon2password.ViolationCode{argon2password.ViolationSequential}},
+		{"keyboard row reversed", argon2password.NISTPolicy{}, "poiuytrewq", []argon2password.ViolationCode{argon2password.ViolationSequential}},
+		{"sequence inside", argon2password.NISTPolicy{}, "x12345678x", nil},
+		{"context word", argon2password.NISTPolicy{ContextWords: []string{"Example"}}, "myexample2024pw", []argon2password.ViolationCode{argon2password.ViolationContextSpecific}},
+		{"email part", argon2password.NISTPolicy{ContextWords: []string{"alice.smith@mail.test"}}, "smith-rocks-forever", []argon2password.ViolationCode{argon2password.ViolationContextSpecific}},
+		{"short context word", argon2password.NISTPolicy{ContextWords: []string{"al"}}, "always blue sky", nil},
+		{"full-width context word", argon2password.NISTPolicy{ContextWords: []string{"alice"}}, "ＡＬＩＣＥ in wonderland", []argon2password.ViolationCode{argon2password.ViolationContextSpecific}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := tt.policy.Check(context.Background(), tt.password)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got := violationCodes(violations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNISTPolicyMaxLengthTooShort(t *testing.T) {
+	for _, maxLength := range []int{1, 16, argon2password.NISTMinMaxLength - 1} {
+		policy := argon2password.NISTPolicy{MaxLength: maxLength}
+		if violations, err := policy.Check(context.Background(), "tulipsandmoss"); !errors.Is(err, argon2password.ErrNISTMaxLength) || violations != nil {
+			t.Errorf("Check() with MaxLength %d = %v, %v, want nil, %v", maxLength, violations, err, argon2password.ErrNISTMaxLength)
+		}
+	}
+	policy := argon2password.NISTPolicy{MaxLength: argon2password.NISTMinMaxLength}
+	if violations, err := policy.Check(context.Background(), "tulipsandmoss"); err != nil || violations != nil {
+		t.Errorf("Check() with MaxLength %d = %v, %v, want nil, nil", argon2password.NISTMinMaxLength, violations, err)
+	}
+}
+
+func TestNISTPolicyWithContextWords(t *testing.T) {
+	policy := argon2password.NISTPolicy{ContextWords: []string{"acme"}}
+	user := policy.WithContextWords("bobby", "bobby@acme.test")
+	if len(policy.ContextWords) != 1 {
+		t.Errorf("WithContextWords() modified the policy: %v", policy.ContextWords)
+	}
+	for _, password := range []string{"acme rocks forever", "bobby rocks forever"} {
+		violations, err := user.Check(context.Background(), password)
+		if err != nil || !reflect.DeepEqual(violationCodes(violations), []argon2password.ViolationCode{argon2password.ViolationContextSpecific}) {
+			t.Errorf("Check(%q) = %v, %v, want context_specific", password, violations, err)
+		}
+	}
+}
+
+func TestNISTPolicyCheckers(t *testing.T) {
+	var checked []string
+	breached := argon2password.PasswordCheckerFunc(func(_ context.Context, password string) ([]argon2password.Violation, error) {
+		checked = append(checked, password)
+		if password == "breached passphrase" {
+			return []argon2password.Violation{{Code: "breached", Message: "Password appeared in a data breach"}}, nil
+		}
+		return nil, nil
+	})
+	policy := argon2password.NISTPolicy{Checkers: []argon2password.PasswordChecker{breached}}
+
+	violations, err := policy.Check(context.Background(), "breached passphrase")
+	if err != nil || !reflect.DeepEqual(violationCodes(violations), []argon2password.ViolationCode{"breached"}) {
+		t.Errorf("Check() = %v, %v, want breached", violations, err)
+	}
+	// Rejected passwords aren't passed to the checkers
+	if _, err := policy.Check(context.Background(), "short"); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	if !reflect.DeepEqual(checked, []string{"breached passphrase"}) {
+		t.Errorf("checkers got %q, want only the password passing the other rules", checked)
+	}
+
+	errChecker := errors.New("service unavailable")
+	policy.Checkers = append(policy.Checkers, argon2password.PasswordCheckerFunc(func(context.Context, string) ([]argon2password.Violation, error) {
+		return nil, errChecker
+	}))
+	if _, err := policy.Check(context.Background(), "some passphrase"); !errors.Is(err, errChecker) {
+		t.Errorf("Check() error = %v, want %v", err, errChecker)
+	}
+}
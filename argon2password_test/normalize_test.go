@@ -0,0 +1,116 @@
+package argon2password_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+const (
+	cafeNFC = "caf\u00e9"  // "é" as a single code point, typed on Windows
+	cafeNFD = "cafe\u0301" // "e" and a combining acute accent, typed on macOS
+)
+
+func TestNormalization(t *testing.T) {
+	tests := []struct {
+		name          string
+		normalization argon2password.Normalization
+		hashed, typed string
+		match         bool
+	}{
+		{"none keeps raw bytes", argon2password.NormalizationNone, cafeNFC, cafeNFD, false},
+		{"nfc composes", argon2password.NormalizationNFC, cafeNFD, cafeNFC, true},
+		{"nfc decomposed both", argon2password.NormalizationNFC, cafeNFD, cafeNFD, true},
+		{"nfc keeps compatibility characters", argon2password.NormalizationNFC, "ﬁle", "file", false},
+		{"nfkc folds compatibility characters", argon2password.NormalizationNFKC, "ﬁle", "file", true},
+		{"nfkc folds full-width", argon2password.NormalizationNFKC, "ＡＢＣ", "ABC", true},
+		{"nfkc composes", argon2password.NormalizationNFKC, cafeNFC, cafeNFD, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testLowCostConfig()
+			config.Normalization = tt.normalization
+			hash, err := argon2password.HashWithConfig(tt.hashed, config)
+			if err != nil {
+				t.Fatalf("HashWithConfig() error = %v", err)
+			}
+			if want := ",n=" + string(tt.normalization) + "$"; tt.normalization != "" && !strings.Contains(hash, want) {
+				t.Errorf("HashWithConfig() = %s, want it to contain %q", hash, want)
+			}
+			// The normalization is read from the hash, the default Config has none
+			match, err := argon2password.ComparePW(tt.typed, hash)
+			if err != nil {
+				t.Fatalf("ComparePW() error = %v", err)
+			}
+			if match != tt.match {
+				t.Errorf("ComparePW(%q) against a hash of %q = %v, want %v", tt.typed, tt.hashed, match, tt.match)
+			}
+		})
+	}
+}
+
+func TestNormalizationWithPreHash(t *testing.T) {
+	config := testLowCostConfig()
+	config.Normalization = argon2password.NormalizationNFC
+	config.PreHash = argon2password.PreHashHMACSHA512V1
+	hash, err := argon2password.HashWithConfig(cafeNFD, config)
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+	if !strings.Contains(hash, ",p=1,n=nfc,ph=hmac-sha512-v1$") {
+		t.Errorf("HashWithConfig() = %s, want n= before ph=", hash)
+	}
+	info, err := argon2password.DecodeHash(hash)
+	if err != nil {
+		t.Fatalf("DecodeHash() error = %v", err)
+	}
+	if info.Normalization != "nfc" || info.PreHash != "hmac-sha512-v1" {
+		t.Errorf("DecodeHash() = %+v, want nfc and hmac-sha512-v1", info)
+	}
+	if match, err := argon2password.ComparePW(cafeNFC, hash); err != nil || !match {
+		t.Errorf("ComparePW() = %v, %v, want true, nil", match, err)
+	}
+
+	// The normalization isn't recorded for wrapped legacy hashes
+	wrapped, err := argon2password.WrapLegacyHashWithConfig(strings.Repeat("ab", 32), argon2password.LegacySHA256, config)
+	if err != nil {
+		t.Fatalf("WrapLegacyHashWithConfig() error = %v", err)
+	}
+	if strings.Contains(wrapped, ",n=") {
+		t.Errorf("WrapLegacyHashWithConfig() = %s, want no normalization", wrapped)
+	}
+}
+
+func TestNormalizationInvalid(t *testing.T) {
+	hash := mustHashLowCost(t, "password")
+	invalid := []string{
+		strings.Replace(hash, ",p=1$", ",p=1,n=nfd$", 1),
+		strings.Replace(hash, ",p=1$", ",p=1,n=$", 1),
+		strings.Replace(hash, ",p=1$", ",p=1,n=nfc,x=1$", 1),
+	}
+	for _, h := range invalid {
+		if _, err := argon2password.ComparePW("password", h); !errors.Is(err, argon2password.ErrUnsupportedNormalization) {
+			t.Errorf("ComparePW(%q) error = %v, want %v", h, err, argon2password.ErrUnsupportedNormalization)
+		}
+	}
+	// ph= comes after n=
+	swapped := strings.Replace(hash, ",p=1$", ",p=1,ph=hmac-sha512-v1,n=nfc$", 1)
+	if _, err := argon2password.ComparePW("password", swapped); !errors.Is(err, argon2password.ErrUnsupportedPreHash) {
+		t.Errorf("ComparePW(%q) error = %v, want %v", swapped, err, argon2password.ErrUnsupportedPreHash)
+	}
+
+	if _, err := argon2password.NewConfig(argon2password.WithNormalization("nfd")); !errors.Is(err, argon2password.ErrUnsupportedNormalization) {
+		t.Errorf("NewConfig() with NFD error = %v, want %v", err, argon2password.ErrUnsupportedNormalization)
+	}
+	t.Setenv("TEST_ARGON2_NORMALIZATION", "nfkc")
+	config, err := argon2password.ConfigFromEnv("TEST_ARGON2_")
+	if err != nil || config.Normalization != argon2password.NormalizationNFKC {
+		t.Errorf("ConfigFromEnv() = %+v, %v, want NFKC", config, err)
+	}
+	t.Setenv("TEST_ARGON2_NORMALIZATION", "nfd")
+	if _, err := argon2password.ConfigFromEnv("TEST_ARGON2_"); !errors.Is(err, argon2password.ErrUnsupportedNormalization) {
+		t.Errorf("ConfigFromEnv() with NFD error = %v, want %v", err, argon2password.ErrUnsupportedNormalization)
+	}
+}
@@ -0,0 +1,137 @@
+package argon2password_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestPasswordRedacted(t *testing.T) {
+	password := argon2password.Password("hunter2")
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d", "%T:%v"} {
+		got := fmt.Sprintf(format, password)
+		if strings.Contains(got, "hunter2") || strings.Contains(got, "68756e74657232") || strings.Contains(got, "104") {
+			t.Errorf("Sprintf(%q) = %q, leaks the password", format, got)
+		}
+	}
+	if got := fmt.Sprint(password); got != "[REDACTED]" {
+		t.Errorf("Sprint() = %q, want [REDACTED]", got)
+	}
+	if got := fmt.Sprintf("%v", struct{ Password argon2password.Password }{password}); got != "{[REDACTED]}" {
+		t.Errorf("Sprintf() of a struct = %q, want {[REDACTED]}", got)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	logger.Info("login", "password", password)
+	logger.Info("login", slog.Any("password", password), slog.Group("form", "password", password))
+	if strings.Contains(logs.String(), "hunter2") || strings.Count(logs.String(), "[REDACTED]") != 3 {
+		t.Errorf("slog output = %s, want the password redacted", logs.String())
+	}
+
+	data, err := json.Marshal(struct {
+		User     string                  `json:"user"`
+		Password argon2password.Password `json:"password"`
+	}{User: "alice", Password: password})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `{"user":"alice","password":"[REDACTED]"}` {
+		t.Errorf("json.Marshal() = %s", data)
+	}
+
+	// Decoding reads the plaintext, not base64
+	var form struct {
+		Password argon2password.Password `json:"password"`
+	}
+	if err := json.Unmarshal([]byte(`{"password": "hunter2"}`), &form); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if string(form.Password) != "hunter2" {
+		t.Errorf("json.Unmarshal() password = %q, want hunter2", string(form.Password))
+	}
+
+	form.Password.Wipe()
+	if !bytes.Equal(form.Password, make([]byte, len("hunter2"))) {
+		t.Errorf("Wipe() left %q", string(form.Password))
+	}
+}
+
+func TestPasswordFromReader(t *testing.T) {
+	password, err := argon2password.PasswordFromReader(iotest.OneByteReader(strings.NewReader("hunter2")), 7)
+	if err != nil {
+		t.Fatalf("PasswordFromReader() error = %v", err)
+	}
+	if string(password) != "hunter2" {
+		t.Errorf("PasswordFromReader() = %q, want hunter2", string(password))
+	}
+	// Read into a single buffer
+	if cap(password) != 8 {
+		t.Errorf("PasswordFromReader() cap = %d, want 8", cap(password))
+	}
+
+	// A large maxLen isn't allocated up front, the buffer grows with the input
+	password, err = argon2password.PasswordFromReader(strings.NewReader("hunter2"), 1<<30)
+	if err != nil || string(password) != "hunter2" || cap(password) > 64 {
+		t.Errorf("PasswordFromReader() with a large maxLen = %q (cap %d), %v, want hunter2", string(password), cap(password), err)
+	}
+	password, err = argon2password.PasswordFromReader(strings.NewReader("hunter2"), math.MaxInt)
+	if err != nil || string(password) != "hunter2" {
+		t.Errorf("PasswordFromReader() with maxLen math.MaxInt = %q, %v, want hunter2", string(password), err)
+	}
+	long := strings.Repeat("correct horse battery staple ", 20)
+	password, err = argon2password.PasswordFromReader(iotest.OneByteReader(strings.NewReader(long)), len(long))
+	if err != nil || string(password) != long {
+		t.Errorf("PasswordFromReader() of %d bytes = %d bytes, %v", len(long), len(password), err)
+	}
+	if _, err := argon2password.PasswordFromReader(strings.NewReader(long), len(long)-1); !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("PasswordFromReader() over a grown maxLen error = %v, want %v", err, argon2password.ErrPasswordTooLong)
+	}
+
+	if _, err := argon2password.PasswordFromReader(strings.NewReader("hunter22"), 7); !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("PasswordFromReader() over maxLen error = %v, want %v", err, argon2password.ErrPasswordTooLong)
+	}
+	if _, err := argon2password.PasswordFromReader(iotest.ErrReader(errors.New("broken")), 7); err == nil {
+		t.Error("PasswordFromReader() with a failing reader error = nil")
+	}
+	if _, err := argon2password.PasswordFromReader(strings.NewReader("x"), 0); !errors.Is(err, argon2password.ErrPasswordMaxLength) {
+		t.Errorf("PasswordFromReader() with maxLen 0 error = %v, want %v", err, argon2password.ErrPasswordMaxLength)
+	}
+
+	b := []byte("hunter2")
+	if p := argon2password.PasswordFromBytes(b); &p[0] != &b[0] {
+		t.Error("PasswordFromBytes() copied the slice")
+	}
+}
+
+func TestHashAndComparePassword(t *testing.T) {
+	password := argon2password.Password("password")
+	hash, err := argon2password.HashPasswordWithConfig(password, testLowCostConfig())
+	if err != nil {
+		t.Fatalf("HashPasswordWithConfig() error = %v", err)
+	}
+	if match, err := argon2password.ComparePassword(password, hash); err != nil || !match {
+		t.Errorf("ComparePassword() = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.ComparePassword(argon2password.Password("wrong"), hash); err != nil || match {
+		t.Errorf("ComparePassword() with wrong password = %v, %v, want false, nil", match, err)
+	}
+	if match, err := argon2password.ComparePasswordWithConfig(password, hash, &argon2password.Config{}); err != nil || !match {
+		t.Errorf("ComparePasswordWithConfig() = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.CompareDummyPassword(password); err != nil || match {
+		t.Errorf("CompareDummyPassword() = %v, %v, want false, nil", match, err)
+	}
+	if _, err := argon2password.HashPassword(nil); !errors.Is(err, argon2password.ErrEmptyPassword) {
+		t.Errorf("HashPassword(nil) error = %v, want %v", err, argon2password.ErrEmptyPassword)
+	}
+}
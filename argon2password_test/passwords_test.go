@@ -2,6 +2,7 @@ package argon2password_test
 
 import (
 	"bytes"
+	"errors"
 	"strings"
 	"testing"
 
@@ -119,6 +120,20 @@ func TestComparePW(t *testing.T) {
 			wantMatch:   false,
 			shouldError: true,
 		},
+		{
+			name:        "Zero iterations",
+			password:    password,
+			hash:        []byte("$argon2id$v=19$m=8192,t=0,p=1$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g"),
+			wantMatch:   false,
+			shouldError: true,
+		},
+		{
+			name:        "Zero parallelism",
+			password:    password,
+			hash:        []byte("$argon2id$v=19$m=8192,t=1,p=0$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g"),
+			wantMatch:   false,
+			shouldError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -319,3 +334,92 @@ func TestHash_StringAliasForHashPW(t *testing.T) {
 		t.Errorf("Hash() produced invalid hash, match = %v, err = %v", match2, err2)
 	}
 }
+
+// TestCompareDummy tests that CompareDummy never matches and rejects the same input as ComparePW
+func TestCompareDummy(t *testing.T) {
+	tests := []struct {
+		name        string
+		password    string
+		shouldError bool
+	}{
+		{name: "Regular password", password: "mysecretpassword", shouldError: false},
+		{name: "Unicode password", password: "пароль密码パスワード", shouldError: false},
+		{name: "Empty password", password: "", shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := argon2password.CompareDummy(tt.password)
+			if tt.shouldError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.shouldError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if match {
+				t.Errorf("CompareDummy() match = true, want false")
+			}
+		})
+	}
+}
+
+// TestDecodeHash tests that DecodeHash returns the parameters a hash was created with
+func TestDecodeHash(t *testing.T) {
+	config := &argon2password.Config{Memory: 8 * argon2password.MiB, Iterations: 2, SaltLength: 24, KeyLength: 48, Parallelism: 1}
+	hash, err := argon2password.HashWithConfig("password", config)
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+
+	info, err := argon2password.DecodeHash(hash)
+	if err != nil {
+		t.Fatalf("DecodeHash() error = %v", err)
+	}
+	want := argon2password.HashInfo{
+		Algorithm:   "argon2id",
+		Version:     19,
+		Memory:      8 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  24,
+		KeyLength:   48,
+	}
+	if *info != want {
+		t.Errorf("DecodeHash() = %+v, want %+v", *info, want)
+	}
+
+	if _, err := argon2password.DecodeHash("not-a-valid-hash"); err == nil {
+		t.Errorf("DecodeHash() with invalid hash expected error but got none")
+	}
+}
+
+func TestParseHashOverLimits(t *testing.T) {
+	// PresetRFC9106First parameters, over the default MaxMemory
+	hash := "$argon2id$v=19$m=2097152,t=1,p=4$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g"
+	if _, err := argon2password.DecodeHash(hash); !errors.Is(err, argon2password.ErrInvalidParams) {
+		t.Errorf("DecodeHash() error = %v, want %v", err, argon2password.ErrInvalidParams)
+	}
+
+	info, err := argon2password.ParseHash(hash)
+	if err != nil {
+		t.Fatalf("ParseHash() error = %v", err)
+	}
+	if info.Memory != 2097152 || info.Iterations != 1 || info.Parallelism != 4 {
+		t.Errorf("ParseHash() = %+v, want m=2097152,t=1,p=4", info)
+	}
+	if !info.ExceedsMaxMemory || info.ExceedsMaxIterations {
+		t.Errorf("ParseHash() exceeds memory, iterations = %v, %v, want true, false", info.ExceedsMaxMemory, info.ExceedsMaxIterations)
+	}
+
+	if _, err := argon2password.ParseHash("not-a-valid-hash"); err == nil {
+		t.Errorf("ParseHash() with invalid hash expected error but got none")
+	}
+
+	// Hashes that would panic in argon2.IDKey don't decode
+	for _, params := range []string{"m=8192,t=0,p=1", "m=8192,t=1,p=0"} {
+		hash := "$argon2id$v=19$" + params + "$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g"
+		if _, err := argon2password.ParseHash(hash); !errors.Is(err, argon2password.ErrInvalidParams) {
+			t.Errorf("ParseHash(%s) error = %v, want %v", params, err, argon2password.ErrInvalidParams)
+		}
+	}
+}
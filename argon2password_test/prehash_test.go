@@ -0,0 +1,112 @@
+package argon2password_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestMaxPasswordLength(t *testing.T) {
+	config := testLowCostConfig()
+	config.MaxPasswordLength = 16
+	hash, err := argon2password.HashWithConfig(strings.Repeat("a", 16), config)
+	if err != nil {
+		t.Fatalf("HashWithConfig() at the max length error = %v", err)
+	}
+
+	_, err = argon2password.HashWithConfig(strings.Repeat("a", 17), config)
+	var tooLong *argon2password.PasswordTooLongError
+	if !errors.As(err, &tooLong) || tooLong.Length != 17 || tooLong.Max != 16 {
+		t.Fatalf("HashWithConfig() over the max length error = %v, want a PasswordTooLongError", err)
+	}
+	if !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("errors.Is(%v, ErrPasswordTooLong) = false", err)
+	}
+
+	if _, err := argon2password.ComparePWWithConfig(strings.Repeat("a", 17), hash, config); !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("ComparePWWithConfig() over the max length error = %v, want %v", err, argon2password.ErrPasswordTooLong)
+	}
+
+	// The default applies to the functions without a Config
+	huge := strings.Repeat("a", int(argon2password.ArgonMaxPasswordLength)+1)
+	if _, err := argon2password.HashPW(huge); !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("HashPW() over the default max length error = %v, want %v", err, argon2password.ErrPasswordTooLong)
+	}
+	if _, err := argon2password.ComparePW(huge, hash); !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("ComparePW() over the default max length error = %v, want %v", err, argon2password.ErrPasswordTooLong)
+	}
+	if _, err := argon2password.CompareDummy(huge); !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("CompareDummy() over the default max length error = %v, want %v", err, argon2password.ErrPasswordTooLong)
+	}
+	if _, err := argon2password.HashFromReader(strings.NewReader(huge), len(huge)-1); !errors.As(err, &tooLong) {
+		t.Errorf("HashFromReader() over maxLen error = %v, want a PasswordTooLongError", err)
+	}
+}
+
+func TestPreHash(t *testing.T) {
+	config := testLowCostConfig()
+	config.PreHash = argon2password.PreHashHMACSHA512V1
+	config.MaxPasswordLength = 1 << 20
+	passphrase := strings.Repeat("correct horse battery staple ", 1000)
+
+	hash, err := argon2password.HashWithConfig(passphrase, config)
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+	if !strings.Contains(hash, ",p=1,ph=hmac-sha512-v1$") {
+		t.Errorf("HashWithConfig() = %s, want the pre-hash recorded after p=", hash)
+	}
+	info, err := argon2password.DecodeHash(hash)
+	if err != nil {
+		t.Fatalf("DecodeHash() error = %v", err)
+	}
+	if info.PreHash != "hmac-sha512-v1" || info.Memory != 8192 {
+		t.Errorf("DecodeHash() = %+v, want the pre-hash and m=8192", info)
+	}
+
+	// Verification follows the hash, not the Config used to verify it
+	limits := &argon2password.Config{MaxPasswordLength: config.MaxPasswordLength}
+	if match, err := argon2password.ComparePWWithConfig(passphrase, hash, limits); err != nil || !match {
+		t.Errorf("ComparePWWithConfig() = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.ComparePWWithConfig(passphrase+"!", hash, limits); err != nil || match {
+		t.Errorf("ComparePWWithConfig() with wrong password = %v, %v, want false, nil", match, err)
+	}
+
+	// A hash without a pre-hash of the same password doesn't match a pre-hashed one
+	plain := testLowCostConfig()
+	plainHash, err := argon2password.HashWithConfig("password", plain)
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+	if match, err := argon2password.ComparePW("password", plainHash); err != nil || !match {
+		t.Errorf("ComparePW() without pre-hash = %v, %v, want true, nil", match, err)
+	}
+	preHashed := strings.Replace(plainHash, ",p=1$", ",p=1,ph=hmac-sha512-v1$", 1)
+	if match, err := argon2password.ComparePW("password", preHashed); err != nil || match {
+		t.Errorf("ComparePW() with an added pre-hash = %v, %v, want false, nil", match, err)
+	}
+
+	invalid := []string{
+		strings.Replace(plainHash, ",p=1$", ",p=1,ph=hmac-sha512-v2$", 1),
+		strings.Replace(plainHash, ",p=1$", ",p=1,ph=$", 1),
+		strings.Replace(plainHash, ",p=1$", ",p=1,ph=hmac-sha512-v1,x=1$", 1),
+	}
+	for _, h := range invalid {
+		if _, err := argon2password.ComparePW("password", h); !errors.Is(err, argon2password.ErrUnsupportedPreHash) {
+			t.Errorf("ComparePW(%q) error = %v, want %v", h, err, argon2password.ErrUnsupportedPreHash)
+		}
+	}
+	if _, err := argon2password.ComparePW("password", strings.Replace(plainHash, ",p=1$", ",p=1,x=1$", 1)); err == nil {
+		t.Error("ComparePW() with an unknown parameter error = nil")
+	}
+
+	if _, err := argon2password.NewConfig(argon2password.WithPreHash("sha256")); !errors.Is(err, argon2password.ErrUnsupportedPreHash) {
+		t.Errorf("NewConfig() with an unknown pre-hash error = %v, want %v", err, argon2password.ErrUnsupportedPreHash)
+	}
+	if _, err := argon2password.NewConfig(argon2password.WithPreHash(argon2password.PreHashHMACSHA512V1)); err != nil {
+		t.Errorf("NewConfig() with PreHashHMACSHA512V1 error = %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package argon2password_test
+
+import (
+	"errors"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestPresets(t *testing.T) {
+	presets := argon2password.Presets()
+	if len(presets) != 7 {
+		t.Fatalf("Presets() returned %d presets, want 7", len(presets))
+	}
+
+	seen := make(map[string]bool)
+	for _, preset := range presets {
+		if preset.Name == "" || preset.Source == "" || preset.Year == 0 {
+			t.Errorf("preset %+v is missing its name, source or year", preset)
+		}
+		if seen[preset.Name] {
+			t.Errorf("duplicate preset name %q", preset.Name)
+		}
+		seen[preset.Name] = true
+
+		found, ok := argon2password.PresetByName(preset.Name)
+		if !ok || found.Name != preset.Name {
+			t.Errorf("PresetByName(%q) = %+v, %v", preset.Name, found, ok)
+		}
+
+		config := preset.Config()
+		if config.MaxMemory < config.Memory || config.MaxIterations < config.Iterations {
+			t.Errorf("preset %q limits m=%d,t=%d below its parameters m=%d,t=%d",
+				preset.Name, config.MaxMemory, config.MaxIterations, config.Memory, config.Iterations)
+		}
+		if !argon2password.MeetsOWASPMinimum(config.Memory, config.Iterations) {
+			t.Errorf("preset %q does not meet the OWASP minimum", preset.Name)
+		}
+	}
+
+	if _, ok := argon2password.PresetByName("unknown"); ok {
+		t.Error("PresetByName(\"unknown\") found a preset")
+	}
+
+	first := argon2password.PresetRFC9106First.Config()
+	if first.Memory != 2*argon2password.GiB || first.Iterations != 1 || first.Parallelism != 4 {
+		t.Errorf("PresetRFC9106First = %+v, want m=2097152,t=1,p=4", first)
+	}
+	second := argon2password.PresetRFC9106Second.Config()
+	if second.Memory != 64*argon2password.MiB || second.Iterations != 3 || second.Parallelism != 4 {
+		t.Errorf("PresetRFC9106Second = %+v, want m=65536,t=3,p=4", second)
+	}
+
+	// Config returns a copy
+	first.Memory = 1
+	if argon2password.PresetRFC9106First.Config().Memory == 1 {
+		t.Error("modifying the result of Config() changed the preset")
+	}
+}
+
+func TestHashWithPreset(t *testing.T) {
+	hash, err := argon2password.HashWithConfig("password", argon2password.PresetOWASP7M5T.Config())
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+	info, err := argon2password.DecodeHash(hash)
+	if err != nil {
+		t.Fatalf("DecodeHash() error = %v", err)
+	}
+	if info.Memory != 7168 || info.Iterations != 5 || info.Parallelism != 1 {
+		t.Errorf("DecodeHash() = %+v, want m=7168,t=5,p=1", info)
+	}
+	if match, err := argon2password.ComparePW("password", hash); err != nil || !match {
+		t.Errorf("ComparePW() = %v, %v, want true, nil", match, err)
+	}
+}
+
+func TestComparePWWithConfig(t *testing.T) {
+	// Over the default iteration limit, but cheap enough to compute
+	config := testLowCostConfig()
+	config.Iterations = argon2password.ArgonMaxIterations + 1
+	hash, err := argon2password.HashWithConfig("password", config)
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+
+	if _, err := argon2password.ComparePW("password", hash); !errors.Is(err, argon2password.ErrInvalidParams) {
+		t.Errorf("ComparePW() error = %v, want %v", err, argon2password.ErrInvalidParams)
+	}
+	if _, err := argon2password.ComparePWWithConfig("password", hash, &argon2password.Config{}); !errors.Is(err, argon2password.ErrInvalidParams) {
+		t.Errorf("ComparePWWithConfig() with default limits error = %v, want %v", err, argon2password.ErrInvalidParams)
+	}
+
+	limits := &argon2password.Config{MaxIterations: config.Iterations}
+	if match, err := argon2password.ComparePWWithConfig("password", hash, limits); err != nil || !match {
+		t.Errorf("ComparePWWithConfig() = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.ComparePWWithConfig("wrong", hash, limits); err != nil || match {
+		t.Errorf("ComparePWWithConfig() with wrong password = %v, %v, want false, nil", match, err)
+	}
+	if _, err := argon2password.ComparePWWithConfig("password", hash, nil); !errors.Is(err, argon2password.ErrConfigNil) {
+		t.Errorf("ComparePWWithConfig() with nil config error = %v, want %v", err, argon2password.ErrConfigNil)
+	}
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package argon2password_test
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// openPTY returns the controller and the terminal end of a new pseudo-terminal
+func openPTY(t *testing.T) (*os.File, *os.File) {
+	t.Helper()
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { ptmx.Close() })
+	if err := unix.IoctlSetPointerInt(int(ptmx.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	n, err := unix.IoctlGetInt(int(ptmx.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	tty, err := os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { tty.Close() })
+	return ptmx, tty
+}
+
+func TestCompareFromReaderTerminal(t *testing.T) {
+	hash := mustHashLowCost(t, "password")
+
+	tests := []struct {
+		name      string
+		input     string
+		wantMatch bool
+		wantErr   error
+	}{
+		{name: "Line", input: "password\n", wantMatch: true},
+		{name: "Exactly maxLen", input: strings.Repeat("a", 16) + "\n", wantMatch: false},
+		{name: "Over maxLen", input: strings.Repeat("a", 17) + "\n", wantErr: argon2password.ErrPasswordTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ptmx, tty := openPTY(t)
+			if _, err := ptmx.WriteString(tt.input); err != nil {
+				t.Fatal(err)
+			}
+			match, err := argon2password.CompareFromReader(tty, 16, hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompareFromReader() from a terminal error = %v, want %v", err, tt.wantErr)
+			}
+			if match != tt.wantMatch {
+				t.Errorf("CompareFromReader() from a terminal = %v, want %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
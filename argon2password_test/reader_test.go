@@ -0,0 +1,81 @@
+package argon2password_test
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestHashFromReader(t *testing.T) {
+	// The rest of the input after the first line is left unread
+	input := strings.NewReader("password\r\nnext line\n")
+	hash, err := argon2password.HashFromReaderWithConfig(input, 16, testLowCostConfig())
+	if err != nil {
+		t.Fatalf("HashFromReaderWithConfig() error = %v", err)
+	}
+	if rest, _ := io.ReadAll(input); string(rest) != "next line\n" {
+		t.Errorf("HashFromReaderWithConfig() consumed past the line, rest = %q", rest)
+	}
+	if match, err := argon2password.ComparePW("password", hash); err != nil || !match {
+		t.Errorf("ComparePW() = %v, %v, want true, nil", match, err)
+	}
+
+	tests := []struct {
+		name      string
+		input     io.Reader
+		wantMatch bool
+		wantErr   error
+	}{
+		{name: "Line", input: strings.NewReader("password\n"), wantMatch: true},
+		{name: "No line ending", input: strings.NewReader("password"), wantMatch: true},
+		{name: "One byte at a time", input: iotest.OneByteReader(strings.NewReader("password\r\n")), wantMatch: true},
+		{name: "Wrong password", input: strings.NewReader("Password\n"), wantMatch: false},
+		{name: "Trailing space is kept", input: strings.NewReader("password \n"), wantMatch: false},
+		{name: "Exactly maxLen", input: strings.NewReader(strings.Repeat("a", 16) + "\r\n"), wantMatch: false},
+		{name: "Over maxLen", input: strings.NewReader(strings.Repeat("a", 17) + "\n"), wantErr: argon2password.ErrPasswordTooLong},
+		{name: "Over maxLen without line ending", input: strings.NewReader(strings.Repeat("a", 100)), wantErr: argon2password.ErrPasswordTooLong},
+		{name: "Empty line", input: strings.NewReader("\n"), wantErr: argon2password.ErrEmptyPassword},
+		{name: "Empty input", input: strings.NewReader(""), wantErr: argon2password.ErrEmptyPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := argon2password.CompareFromReader(tt.input, 16, hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompareFromReader() error = %v, want %v", err, tt.wantErr)
+			}
+			if match != tt.wantMatch {
+				t.Errorf("CompareFromReader() = %v, want %v", match, tt.wantMatch)
+			}
+		})
+	}
+
+	if _, err := argon2password.CompareFromReader(iotest.ErrReader(errors.New("broken")), 16, hash); err == nil {
+		t.Error("CompareFromReader() with a failing reader error = nil")
+	}
+	// A huge maxLen isn't allocated up front and doesn't overflow, the buffer grows with the line
+	for _, maxLen := range []int{math.MaxInt, math.MaxInt - 2, 1 << 40} {
+		if match, err := argon2password.CompareFromReader(strings.NewReader("password\n"), maxLen, hash); err != nil || !match {
+			t.Errorf("CompareFromReader() with maxLen %d = %v, %v, want true, nil", maxLen, match, err)
+		}
+	}
+	long := strings.Repeat("correct horse battery staple ", 20)
+	longHash := mustHashLowCost(t, long)
+	if match, err := argon2password.CompareFromReader(iotest.OneByteReader(strings.NewReader(long+"\r\nnext\n")), math.MaxInt, longHash); err != nil || !match {
+		t.Errorf("CompareFromReader() of a %d byte line = %v, %v, want true, nil", len(long), match, err)
+	}
+	if _, err := argon2password.CompareFromReader(strings.NewReader(long+"\n"), len(long)-1, longHash); !errors.Is(err, argon2password.ErrPasswordTooLong) {
+		t.Errorf("CompareFromReader() over a grown maxLen error = %v, want %v", err, argon2password.ErrPasswordTooLong)
+	}
+
+	if _, err := argon2password.HashFromReader(strings.NewReader("password\n"), 0); !errors.Is(err, argon2password.ErrPasswordMaxLength) {
+		t.Errorf("HashFromReader() with maxLen 0 error = %v, want %v", err, argon2password.ErrPasswordMaxLength)
+	}
+	if _, err := argon2password.HashFromReaderWithConfig(strings.NewReader("password\n"), 16, nil); !errors.Is(err, argon2password.ErrConfigNil) {
+		t.Errorf("HashFromReaderWithConfig() with nil config error = %v, want %v", err, argon2password.ErrConfigNil)
+	}
+}
@@ -0,0 +1,100 @@
+package argon2password_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestSecureBuffer(t *testing.T) {
+	src := []byte("correct horse battery staple")
+	buf, err := argon2password.NewSecureBufferFrom(src)
+	if err != nil {
+		t.Fatalf("NewSecureBufferFrom() error = %v", err)
+	}
+	defer buf.Destroy()
+
+	if !bytes.Equal(src, make([]byte, len(src))) {
+		t.Errorf("NewSecureBufferFrom() left the source as %q, want it wiped", src)
+	}
+	if got := string(buf.Bytes()); got != "correct horse battery staple" {
+		t.Errorf("Bytes() = %q", got)
+	}
+	if buf.Len() != len(src) {
+		t.Errorf("Len() = %d, want %d", buf.Len(), len(src))
+	}
+	t.Logf("Locked() = %v", buf.Locked())
+
+	buf.Destroy()
+	buf.Destroy() // safe to repeat
+	if buf.Bytes() != nil || buf.Len() != 0 || buf.Locked() {
+		t.Errorf("after Destroy() Bytes() = %q, Len() = %d, Locked() = %v", buf.Bytes(), buf.Len(), buf.Locked())
+	}
+
+	empty, err := argon2password.NewSecureBuffer(0)
+	if err != nil {
+		t.Fatalf("NewSecureBuffer(0) error = %v", err)
+	}
+	defer empty.Destroy()
+	if empty.Len() != 0 {
+		t.Errorf("NewSecureBuffer(0).Len() = %d", empty.Len())
+	}
+
+	if _, err := argon2password.NewSecureBuffer(-1); !errors.Is(err, argon2password.ErrSecureBufferSize) {
+		t.Errorf("NewSecureBuffer(-1) error = %v, want %v", err, argon2password.ErrSecureBufferSize)
+	}
+}
+
+func TestHashAndCompareSecure(t *testing.T) {
+	newBuffer := func(password string) *argon2password.SecureBuffer {
+		t.Helper()
+		buf, err := argon2password.NewSecureBufferFrom([]byte(password))
+		if err != nil {
+			t.Fatalf("NewSecureBufferFrom() error = %v", err)
+		}
+		t.Cleanup(buf.Destroy)
+		return buf
+	}
+	password := newBuffer("password")
+
+	hash, err := argon2password.HashWithConfigSecure(password, testLowCostConfig())
+	if err != nil {
+		t.Fatalf("HashWithConfigSecure() error = %v", err)
+	}
+	if match, err := argon2password.ComparePWSecure(password, hash); err != nil || !match {
+		t.Errorf("ComparePWSecure() = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.ComparePWSecure(newBuffer("wrong"), hash); err != nil || match {
+		t.Errorf("ComparePWSecure() with wrong password = %v, %v, want false, nil", match, err)
+	}
+	if match, err := argon2password.ComparePW("password", hash); err != nil || !match {
+		t.Errorf("ComparePW() of a SecureBuffer hash = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.ComparePWWithConfigSecure(password, hash, &argon2password.Config{}); err != nil || !match {
+		t.Errorf("ComparePWWithConfigSecure() = %v, %v, want true, nil", match, err)
+	}
+	if match, err := argon2password.CompareDummySecure(password); err != nil || match {
+		t.Errorf("CompareDummySecure() = %v, %v, want false, nil", match, err)
+	}
+	// The buffer is left intact for the caller to destroy
+	if got := string(password.Bytes()); got != "password" {
+		t.Errorf("after hashing Bytes() = %q, want %q", got, "password")
+	}
+
+	destroyed := newBuffer("password")
+	destroyed.Destroy()
+	if _, err := argon2password.ComparePWSecure(destroyed, hash); !errors.Is(err, argon2password.ErrSecureBufferDestroyed) {
+		t.Errorf("ComparePWSecure() with a destroyed buffer error = %v, want %v", err, argon2password.ErrSecureBufferDestroyed)
+	}
+	if _, err := argon2password.HashPWSecure(nil); !errors.Is(err, argon2password.ErrEmptyPassword) {
+		t.Errorf("HashPWSecure(nil) error = %v, want %v", err, argon2password.ErrEmptyPassword)
+	}
+	if _, err := argon2password.HashWithConfigSecure(newBuffer(""), testLowCostConfig()); !errors.Is(err, argon2password.ErrEmptyPassword) {
+		t.Errorf("HashWithConfigSecure() with an empty buffer error = %v, want %v", err, argon2password.ErrEmptyPassword)
+	}
+	if _, err := argon2password.HashWithConfigSecure(password, nil); !errors.Is(err, argon2password.ErrConfigNil) {
+		t.Errorf("HashWithConfigSecure() with nil config error = %v, want %v", err, argon2password.ErrConfigNil)
+	}
+}
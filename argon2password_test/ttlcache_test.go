@@ -0,0 +1,71 @@
+package argon2password_test
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.hlmpn.dev/pkg/argon2password/internal/ttlcache"
+)
+
+func TestTTLCache(t *testing.T) {
+	cache := ttlcache.New[string, int](time.Hour, 2)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Get() on an empty cache found an entry")
+	}
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	if v, ok := cache.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v, want 1, true", v, ok)
+	}
+
+	// Replacing an entry doesn't evict another one
+	cache.Add("b", 3)
+	if v, ok := cache.Get("b"); !ok || v != 3 || cache.Len() != 2 {
+		t.Errorf("Get(b) = %d, %v with %d entries, want 3, true with 2", v, ok, cache.Len())
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("Add() of an existing key evicted another entry")
+	}
+
+	// A full cache evicts an entry to make room
+	cache.Add("c", 4)
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	if v, ok := cache.Get("c"); !ok || v != 4 {
+		t.Errorf("Get(c) = %d, %v, want 4, true", v, ok)
+	}
+}
+
+func TestTTLCacheExpiry(t *testing.T) {
+	cache := ttlcache.New[string, int](50*time.Millisecond, 2)
+	cache.Add("old", 1)
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := cache.Get("old"); ok {
+		t.Error("Get() returned an expired entry")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() after Get of an expired entry = %d, want 0", cache.Len())
+	}
+
+	// Expired entries are evicted before live ones
+	cache.Add("old", 1)
+	cache.Add("live", 2)
+	time.Sleep(100 * time.Millisecond)
+	cache.Add("live", 2)
+	cache.Add("new", 3)
+	if _, ok := cache.Get("live"); !ok {
+		t.Error("Add() evicted a live entry while an expired one was left")
+	}
+	if _, ok := cache.Get("new"); !ok {
+		t.Error("Get(new) missed")
+	}
+}
+
+func TestTTLCacheNil(t *testing.T) {
+	var cache *ttlcache.Cache[string, int]
+	cache.Add("a", 1)
+	if _, ok := cache.Get("a"); ok || cache.Len() != 0 {
+		t.Error("a nil Cache held an entry")
+	}
+}
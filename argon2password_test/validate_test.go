@@ -0,0 +1,78 @@
+package argon2password_test
+
+import (
+	"reflect"
+	"testing"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func violationCodes(violations []argon2password.Violation) []argon2password.ViolationCode {
+	var codes []argon2password.ViolationCode
+	for _, v := range violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestValidate(t *testing.T) {
+	all := argon2password.PasswordRequirements{
+		HasLowercase: true,
+		HasUppercase: true,
+		HasNumber:    true,
+		HasSpecial:   true,
+		MinLength:    8,
+		MaxLength:    16,
+	}
+	tests := []struct {
+		name     string
+		password string
+		req      argon2password.PasswordRequirements
+		want     []argon2password.ViolationCode
+	}{
+		{"valid", "Abcdef1!", all, nil},
+		{"empty requirements", "", argon2password.PasswordRequirements{}, nil},
+		{"empty password", "", all, []argon2password.ViolationCode{
+			argon2password.ViolationTooShort,
+			argon2password.ViolationMissingLowercase,
+			argon2password.ViolationMissingUppercase,
+			argon2password.ViolationMissingNumber,
+			argon2password.ViolationMissingSpecial,
+		}},
+		{"too long", "Abcdef1!Abcdef1!x", all, []argon2password.ViolationCode{argon2password.ViolationTooLong}},
+		{"only lowercase", "abcdefgh", all, []argon2password.ViolationCode{
+			argon2password.ViolationMissingUppercase,
+			argon2password.ViolationMissingNumber,
+			argon2password.ViolationMissingSpecial,
+		}},
+		// 7 runes but 9 bytes, IsValid would count 9
+		{"length in runes", "Ábcdé1!", all, []argon2password.ViolationCode{argon2password.ViolationTooShort}},
+		{"unicode classes", "ÉCOLE été 1€", all, nil},
+		{"min counts", "Abc1!xyz", argon2password.PasswordRequirements{MinUppercase: 2, MinNumbers: 2, MinSpecial: 1}, []argon2password.ViolationCode{
+			argon2password.ViolationMissingUppercase,
+			argon2password.ViolationMissingNumber,
+		}},
+		{"min count overrides bool", "A1b", argon2password.PasswordRequirements{HasNumber: true, MinNumbers: 3}, []argon2password.ViolationCode{
+			argon2password.ViolationMissingNumber,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violationCodes(argon2password.Validate(tt.password, tt.req)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateViolationDetails(t *testing.T) {
+	violations := argon2password.Validate("Abc1", argon2password.PasswordRequirements{MinLength: 12, MinNumbers: 2, HasSpecial: true})
+	want := []argon2password.Violation{
+		{Code: argon2password.ViolationTooShort, Message: "Password must be at least 12 characters long", Required: 12, Actual: 4},
+		{Code: argon2password.ViolationMissingNumber, Message: "Password must contain at least 2 digits", Required: 2, Actual: 1},
+		{Code: argon2password.ViolationMissingSpecial, Message: "Password must contain at least 1 special character", Required: 1, Actual: 0},
+	}
+	if !reflect.DeepEqual(violations, want) {
+		t.Errorf("Validate() = %+v, want %+v", violations, want)
+	}
+}
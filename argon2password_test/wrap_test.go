@@ -0,0 +1,163 @@
+package argon2password_test
+
+import (
+	"crypto/md5"  //nolint:gosec // G501, legacy hashes under test
+	"crypto/sha1" //nolint:gosec // G505, legacy hashes under test
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	argon2password "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func TestWrapLegacyHash(t *testing.T) {
+	const password = "legacy-password"
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt.GenerateFromPassword() error = %v", err)
+	}
+	md5Sum := md5.Sum([]byte(password))   //nolint:gosec // G401
+	sha1Sum := sha1.Sum([]byte(password)) //nolint:gosec // G401
+	sha256Sum := sha256.Sum256([]byte(password))
+	sha512Sum := sha512.Sum512([]byte(password))
+
+	tests := []struct {
+		name       string
+		legacy     string
+		scheme     argon2password.LegacyScheme
+		wantScheme argon2password.LegacyScheme
+	}{
+		{name: "Bcrypt 2a", legacy: string(bcryptHash), wantScheme: argon2password.LegacyBcrypt},
+		{name: "Bcrypt 2b", legacy: "$2b$" + string(bcryptHash[4:]), wantScheme: argon2password.LegacyBcrypt},
+		{name: "Bcrypt 2y", legacy: "$2y$" + string(bcryptHash[4:]), wantScheme: argon2password.LegacyBcrypt},
+		{name: "MD5", legacy: hex.EncodeToString(md5Sum[:]), wantScheme: argon2password.LegacyMD5},
+		{name: "SHA-1 uppercase", legacy: strings.ToUpper(hex.EncodeToString(sha1Sum[:])), wantScheme: argon2password.LegacySHA1},
+		{name: "SHA-256", legacy: hex.EncodeToString(sha256Sum[:]), wantScheme: argon2password.LegacySHA256},
+		{name: "SHA-512 explicit", legacy: hex.EncodeToString(sha512Sum[:]), scheme: argon2password.LegacySHA512, wantScheme: argon2password.LegacySHA512},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped, err := argon2password.WrapLegacyHashWithConfig(tt.legacy, tt.scheme, testLowCostConfig())
+			if err != nil {
+				t.Fatalf("WrapLegacyHashWithConfig() error = %v", err)
+			}
+			if !strings.HasPrefix(wrapped, "$argon2id-wrap$") || !argon2password.IsWrappedHash(wrapped) {
+				t.Fatalf("WrapLegacyHashWithConfig() = %q, want a wrapped hash", wrapped)
+			}
+
+			match, err := argon2password.ComparePW(password, wrapped)
+			if err != nil || !match {
+				t.Errorf("ComparePW() = %v, %v, want true, nil", match, err)
+			}
+			match, err = argon2password.ComparePW("wrong-password", wrapped)
+			if err != nil || match {
+				t.Errorf("ComparePW() with wrong password = %v, %v, want false, nil", match, err)
+			}
+
+			info, err := argon2password.DecodeHash(wrapped)
+			if err != nil {
+				t.Fatalf("DecodeHash() error = %v", err)
+			}
+			if info.Wrapped != string(tt.wantScheme) || info.Memory != 8*1024 {
+				t.Errorf("DecodeHash() = %+v, want wrapped %q with m=8192", info, tt.wantScheme)
+			}
+			if result := argon2password.ClassifyHash(wrapped, nil); result.Class != argon2password.AuditWrapped {
+				t.Errorf("ClassifyHash() class = %q, want %q", result.Class, argon2password.AuditWrapped)
+			}
+		})
+	}
+}
+
+func TestWrapLegacyHashErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		legacy  string
+		scheme  argon2password.LegacyScheme
+		wantErr error
+	}{
+		{name: "Unknown format", legacy: "not-a-hash", wantErr: argon2password.ErrUnsupportedLegacyScheme},
+		{name: "MD5 crypt", legacy: "$1$saltsalt$qjXMvbEw8oaL.CzflDugX/", wantErr: argon2password.ErrUnsupportedLegacyScheme},
+		{name: "Unknown scheme", legacy: "5f4dcc3b5aa765d61d8327deb882cf99", scheme: "md4", wantErr: argon2password.ErrUnsupportedLegacyScheme},
+		{name: "Scheme mismatch", legacy: "5f4dcc3b5aa765d61d8327deb882cf99", scheme: argon2password.LegacySHA1, wantErr: argon2password.ErrInvalidLegacyHash},
+		{name: "Short bcrypt", legacy: "$2b$10$abcdefghijklmnopqrstuu", wantErr: argon2password.ErrInvalidLegacyHash},
+		{name: "Bcrypt cost", legacy: "$2b$03$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyzabc", wantErr: argon2password.ErrInvalidLegacyHash},
+		{name: "Bcrypt over max cost", legacy: "$2b$17$" + testBcryptHash[7:], wantErr: argon2password.ErrInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := argon2password.WrapLegacyHashWithConfig(tt.legacy, tt.scheme, testLowCostConfig()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("WrapLegacyHashWithConfig() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := argon2password.WrapLegacyHashWithConfig("5f4dcc3b5aa765d61d8327deb882cf99", "", nil); !errors.Is(err, argon2password.ErrConfigNil) {
+		t.Errorf("WrapLegacyHashWithConfig() with nil config error = %v, want %v", err, argon2password.ErrConfigNil)
+	}
+}
+
+func TestCompareWrappedHashTampered(t *testing.T) {
+	wrapped, err := argon2password.WrapLegacyHashWithConfig("5f4dcc3b5aa765d61d8327deb882cf99", "", testLowCostConfig())
+	if err != nil {
+		t.Fatalf("WrapLegacyHashWithConfig() error = %v", err)
+	}
+
+	// Changing the inner scheme changes the argon2id input
+	tampered := strings.Replace(wrapped, "$md5$", "$sha1$", 1)
+	if match, err := argon2password.ComparePW("password", tampered); err != nil || match {
+		t.Errorf("ComparePW() with tampered scheme = %v, %v, want false, nil", match, err)
+	}
+
+	unknown := strings.Replace(wrapped, "$md5$", "$md4$", 1)
+	if _, err := argon2password.ComparePW("password", unknown); !errors.Is(err, argon2password.ErrUnsupportedLegacyScheme) {
+		t.Errorf("ComparePW() with unknown scheme error = %v, want %v", err, argon2password.ErrUnsupportedLegacyScheme)
+	}
+
+	dos := strings.Replace(wrapped, "m=8192", "m=2097152", 1)
+	if _, err := argon2password.ComparePW("password", dos); !errors.Is(err, argon2password.ErrInvalidParams) {
+		t.Errorf("ComparePW() over the limits error = %v, want %v", err, argon2password.ErrInvalidParams)
+	}
+	if result := argon2password.ClassifyHash(dos, nil); result.Class != argon2password.AuditDoSRisk {
+		t.Errorf("ClassifyHash() class = %q, want %q", result.Class, argon2password.AuditDoSRisk)
+	}
+}
+
+// testBcryptHash is a well-formed bcrypt hash with cost 10
+const testBcryptHash = "$2b$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+
+func TestWrappedBcryptCostLimit(t *testing.T) {
+	const legacy = testBcryptHash
+	wrapped, err := argon2password.WrapLegacyHashWithConfig(legacy, "", testLowCostConfig())
+	if err != nil {
+		t.Fatalf("WrapLegacyHashWithConfig() error = %v", err)
+	}
+
+	// A cost of 31 would spend hours on the legacy hash before argon2id
+	dos := strings.Replace(wrapped, ",2b,10,", ",2b,31,", 1)
+	if _, err := argon2password.ComparePW("password", dos); !errors.Is(err, argon2password.ErrInvalidParams) {
+		t.Errorf("ComparePW() over the bcrypt cost limit error = %v, want %v", err, argon2password.ErrInvalidParams)
+	}
+	if _, err := argon2password.DecodeHash(dos); !errors.Is(err, argon2password.ErrInvalidParams) {
+		t.Errorf("DecodeHash() over the bcrypt cost limit error = %v, want %v", err, argon2password.ErrInvalidParams)
+	}
+	if info, err := argon2password.ParseHash(dos); err != nil || !info.ExceedsMaxBcryptCost {
+		t.Errorf("ParseHash() = %+v, %v, want ExceedsMaxBcryptCost", info, err)
+	}
+	if result := argon2password.ClassifyHash(dos, nil); result.Class != argon2password.AuditDoSRisk {
+		t.Errorf("ClassifyHash() class = %q, want %q", result.Class, argon2password.AuditDoSRisk)
+	}
+	if result := argon2password.ClassifyHash(strings.Replace(legacy, "$10$", "$17$", 1), nil); result.Class != argon2password.AuditDoSRisk {
+		t.Errorf("ClassifyHash() of a bcrypt hash over the cost limit class = %q, want %q", result.Class, argon2password.AuditDoSRisk)
+	}
+	if result := argon2password.ClassifyHash(legacy, nil); result.Class != argon2password.AuditLegacy {
+		t.Errorf("ClassifyHash() class = %q, want %q", result.Class, argon2password.AuditLegacy)
+	}
+}
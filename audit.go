@@ -0,0 +1,341 @@
+package argon2password
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AuditClass is the category a stored hash falls into.
+type AuditClass string
+
+// Audit categories
+const (
+	// AuditCurrent is an argon2id hash at least as strong as the reference parameters.
+	AuditCurrent AuditClass = "argon2id-current"
+	// AuditWeak is an argon2id hash below the reference parameters, it should be rehashed on next login.
+	AuditWeak AuditClass = "argon2id-weak"
+	// AuditWrapped is a legacy hash wrapped in argon2id, it should be rehashed on next login.
+	AuditWrapped AuditClass = "argon2id-wrapped"
+	// AuditLegacy is a hash from another scheme (bcrypt, MD5, SHA-1, ...).
+	AuditLegacy AuditClass = "legacy"
+	// AuditMalformed is an entry that could not be parsed.
+	AuditMalformed AuditClass = "malformed"
+	// AuditDoSRisk is a hash whose parameters exceed the verification limits: an argon2id
+	// hash over MaxMemory or MaxIterations, or a bcrypt hash over LegacyMaxBcryptCost.
+	// ComparePW refuses to verify it and WrapLegacyHash to wrap it.
+	AuditDoSRisk AuditClass = "dos-risk"
+)
+
+// AuditClasses lists all categories in report order.
+var AuditClasses = []AuditClass{AuditCurrent, AuditWeak, AuditWrapped, AuditLegacy, AuditMalformed, AuditDoSRisk}
+
+// AuditResult is the classification of a single stored hash.
+// It never contains the hash itself.
+type AuditResult struct {
+	Line   int        `json:"line"`             // 1-based line or record number in the input
+	ID     string     `json:"id,omitempty"`     // value of AuditOptions.IDColumn, if set
+	Class  AuditClass `json:"class"`            // category of the hash
+	Scheme string     `json:"scheme,omitempty"` // detected scheme, e.g. "argon2id" or "bcrypt"
+	Reason string     `json:"reason,omitempty"` // why the hash is not current
+}
+
+// AuditReport summarizes an audit.
+type AuditReport struct {
+	Total   int                `json:"total"`
+	Counts  map[AuditClass]int `json:"counts"`
+	Schemes map[string]int     `json:"schemes"`
+	Results []AuditResult      `json:"results,omitempty"`
+}
+
+// AuditOptions controls how Audit reads its input. The zero value reads one hash per line.
+type AuditOptions struct {
+	// Reference is the Config hashes are compared against.
+	// Hashes with lower memory, iterations, salt or key length are weak,
+	// and hashes over MaxMemory or MaxIterations are a DoS risk.
+	// Defaults to DefaultConfig if nil.
+	Reference *Config
+
+	// CSVColumn is the 1-based column holding the hash when the input is CSV.
+	// Encoded argon2id hashes contain commas, so the field must be quoted
+	// or another Comma used.
+	// The input is read as one hash per line if unset(0).
+	CSVColumn int
+
+	// IDColumn is the 1-based CSV column copied into AuditResult.ID, e.g. a user id.
+	IDColumn int
+
+	// Comma is the CSV field separator, defaults to ','.
+	Comma rune
+
+	// SkipHeader skips the first line or record.
+	SkipHeader bool
+
+	// SummaryOnly leaves AuditReport.Results empty, for large dumps.
+	SummaryOnly bool
+}
+
+// legacySchemes maps hash prefixes to the name of their scheme
+var legacySchemes = []struct {
+	prefix string
+	scheme string
+}{
+	{prefix: "$2a$", scheme: "bcrypt"},
+	{prefix: "$2b$", scheme: "bcrypt"},
+	{prefix: "$2x$", scheme: "bcrypt"},
+	{prefix: "$2y$", scheme: "bcrypt"},
+	{prefix: "$1$", scheme: "md5-crypt"},
+	{prefix: "$apr1$", scheme: "apr1-md5"},
+	{prefix: "$5$", scheme: "sha256-crypt"},
+	{prefix: "$6$", scheme: "sha512-crypt"},
+	{prefix: "$7$", scheme: "scrypt"},
+	{prefix: "$scrypt$", scheme: "scrypt"},
+	{prefix: "$argon2i$", scheme: "argon2i"},
+	{prefix: "$argon2d$", scheme: "argon2d"},
+	{prefix: "$pbkdf2", scheme: "pbkdf2"},
+	{prefix: "pbkdf2_", scheme: "pbkdf2"},
+	{prefix: "{SHA}", scheme: "ldap-sha1"},
+	{prefix: "{SSHA}", scheme: "ldap-ssha1"},
+}
+
+// Unsalted hex digests by length
+var legacyHexDigests = map[int]string{
+	32:  "md5",
+	40:  "sha1",
+	64:  "sha256",
+	128: "sha512",
+}
+
+// ClassifyHash classifies a single stored hash against reference.
+// reference defaults to DefaultConfig if nil.
+func ClassifyHash(hash string, reference *Config) AuditResult {
+	ref := auditReference(reference)
+	hash = strings.TrimSpace(hash)
+
+	if IsWrappedHash(hash) {
+		return classifyWrappedHash(hash, ref)
+	}
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		if scheme, ok := legacyScheme(hash); ok {
+			return classifyLegacyHash(hash, scheme)
+		}
+		return AuditResult{Class: AuditMalformed, Reason: "unrecognized format"}
+	}
+
+	h, err := parseArgonHashBytes([]byte(hash))
+	if err != nil {
+		return AuditResult{Class: AuditMalformed, Scheme: argon2id, Reason: err.Error()}
+	}
+	if exceedsArgonLimits(h, ref.MaxMemory, ref.MaxIterations) {
+		return AuditResult{
+			Class:  AuditDoSRisk,
+			Scheme: argon2id,
+			Reason: fmt.Sprintf("m=%d,t=%d exceeds max m=%d,t=%d", h.memory, h.iterations, ref.MaxMemory, ref.MaxIterations),
+		}
+	}
+
+	var reasons []string
+	if MemorySize(h.memory) < ref.Memory {
+		reasons = append(reasons, fmt.Sprintf("memory %d < %d", h.memory, ref.Memory))
+	}
+	if h.iterations < ref.Iterations {
+		reasons = append(reasons, fmt.Sprintf("iterations %d < %d", h.iterations, ref.Iterations))
+	}
+	if len(h.salt) < int(ref.SaltLength) {
+		reasons = append(reasons, fmt.Sprintf("salt length %d < %d", len(h.salt), ref.SaltLength))
+	}
+	if len(h.hash) < int(ref.KeyLength) {
+		reasons = append(reasons, fmt.Sprintf("key length %d < %d", len(h.hash), ref.KeyLength))
+	}
+	if len(reasons) > 0 {
+		return AuditResult{Class: AuditWeak, Scheme: argon2id, Reason: strings.Join(reasons, ", ")}
+	}
+	return AuditResult{Class: AuditCurrent, Scheme: argon2id}
+}
+
+// classifyWrappedHash classifies a wrapped legacy hash, the argon2id
+// parameters only matter for the DoS limits as it is rehashed on next login
+func classifyWrappedHash(hash string, ref Config) AuditResult {
+	params, argonHash, err := splitWrappedHash([]byte(hash))
+	if err != nil {
+		return AuditResult{Class: AuditMalformed, Scheme: argon2idWrap, Reason: err.Error()}
+	}
+	scheme := argon2idWrap + "+" + string(params.scheme)
+	h, err := parseArgonHashBytes(argonHash)
+	if err != nil {
+		return AuditResult{Class: AuditMalformed, Scheme: scheme, Reason: err.Error()}
+	}
+	if params.exceedsLimits() {
+		return AuditResult{Class: AuditDoSRisk, Scheme: scheme, Reason: bcryptCostReason(params.cost)}
+	}
+	if exceedsArgonLimits(h, ref.MaxMemory, ref.MaxIterations) {
+		return AuditResult{
+			Class:  AuditDoSRisk,
+			Scheme: scheme,
+			Reason: fmt.Sprintf("m=%d,t=%d exceeds max m=%d,t=%d", h.memory, h.iterations, ref.MaxMemory, ref.MaxIterations),
+		}
+	}
+	return AuditResult{Class: AuditWrapped, Scheme: scheme, Reason: string(params.scheme) + " hash wrapped in argon2id"}
+}
+
+// classifyLegacyHash classifies a hash from another scheme, bcrypt
+// hashes too costly to wrap and verify are a DoS risk
+func classifyLegacyHash(hash string, scheme string) AuditResult {
+	if scheme == string(LegacyBcrypt) {
+		if params, _, err := parseLegacyHash(hash, LegacyBcrypt); err == nil && params.exceedsLimits() {
+			return AuditResult{Class: AuditDoSRisk, Scheme: scheme, Reason: bcryptCostReason(params.cost)}
+		}
+	}
+	return AuditResult{Class: AuditLegacy, Scheme: scheme}
+}
+
+func bcryptCostReason(cost int) string {
+	return fmt.Sprintf("bcrypt cost %d exceeds max %d", cost, LegacyMaxBcryptCost)
+}
+
+// Audit reads stored hashes from r and classifies each one, see ClassifyHash.
+// Empty lines are skipped. opts may be nil.
+// On a read error the report covers the input read so far.
+func Audit(r io.Reader, opts *AuditOptions) (*AuditReport, error) {
+	if opts == nil {
+		opts = &AuditOptions{}
+	}
+	if opts.CSVColumn < 0 || opts.IDColumn < 0 {
+		return nil, ErrAuditColumn
+	}
+
+	report := &AuditReport{
+		Counts:  make(map[AuditClass]int, len(AuditClasses)),
+		Schemes: make(map[string]int),
+	}
+	for _, class := range AuditClasses {
+		report.Counts[class] = 0
+	}
+
+	add := func(line int, id, hash string) {
+		result := ClassifyHash(hash, opts.Reference)
+		result.Line = line
+		result.ID = id
+		report.Total++
+		report.Counts[result.Class]++
+		if result.Scheme != "" {
+			report.Schemes[result.Scheme]++
+		}
+		if !opts.SummaryOnly {
+			report.Results = append(report.Results, result)
+		}
+	}
+
+	if opts.CSVColumn == 0 {
+		return report, auditLines(r, opts, add)
+	}
+	return report, auditCSV(r, opts, add)
+}
+
+func auditLines(r io.Reader, opts *AuditOptions, add func(line int, id, hash string)) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line == 1 && opts.SkipHeader {
+			continue
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		add(line, "", text)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("argon2Password: failed to read audit input: %w", err)
+	}
+	return nil
+}
+
+func auditCSV(r io.Reader, opts *AuditOptions, add func(line int, id, hash string)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // dumps are not always consistent, short rows are reported as malformed
+	reader.ReuseRecord = true
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+
+	record := 0
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("argon2Password: failed to read audit CSV: %w", err)
+		}
+		record++
+		if record == 1 && opts.SkipHeader {
+			continue
+		}
+
+		var id string
+		if opts.IDColumn > 0 && opts.IDColumn <= len(fields) {
+			id = fields[opts.IDColumn-1]
+		}
+		var hash string
+		if opts.CSVColumn <= len(fields) {
+			hash = fields[opts.CSVColumn-1]
+		}
+		add(record, id, hash)
+	}
+}
+
+// legacyScheme detects well known non-argon2id formats
+func legacyScheme(hash string) (string, bool) {
+	for _, legacy := range legacySchemes {
+		if strings.HasPrefix(hash, legacy.prefix) {
+			return legacy.scheme, true
+		}
+	}
+	if scheme, ok := legacyHexDigests[len(hash)]; ok && isHex(hash) {
+		return scheme, true
+	}
+	return "", false
+}
+
+func isHex(s string) bool {
+	for i := range len(s) {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// auditReference fills the unset fields of reference with the default Config
+func auditReference(reference *Config) Config {
+	ref := Config{}
+	if reference != nil {
+		ref = *reference
+	}
+	defaults := currentConfig()
+	if ref.Memory == 0 {
+		ref.Memory = defaults.Memory
+	}
+	if ref.Iterations == 0 {
+		ref.Iterations = defaults.Iterations
+	}
+	if ref.SaltLength == 0 {
+		ref.SaltLength = defaults.SaltLength
+	}
+	if ref.KeyLength == 0 {
+		ref.KeyLength = defaults.KeyLength
+	}
+	if ref.MaxMemory == 0 {
+		ref.MaxMemory = defaults.MaxMemory
+	}
+	if ref.MaxIterations == 0 {
+		ref.MaxIterations = defaults.MaxIterations
+	}
+	return ref
+}
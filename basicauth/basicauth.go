@@ -0,0 +1,239 @@
+// Package basicauth provides net/http middleware that checks HTTP Basic Auth
+// credentials against argon2id hashes using argon2password.ComparePW.
+//
+// Unknown users are verified against a dummy hash so every failed login costs
+// the same, and the number of concurrent Argon2id computations is bounded so a
+// burst of requests can't exhaust memory.
+package basicauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+
+	// DefaultRealm is used when Options.Realm is empty.
+	DefaultRealm = "Restricted"
+
+	// DefaultMaxConcurrent is the default number of concurrent verifications,
+	// lowered to argon2password.MaxConcurrentHashes in a memory limited container.
+	// Each one allocates the memory of the stored hash (64 MiB by default).
+	DefaultMaxConcurrent = 4
+)
+
+// Options configures an Authenticator. The zero value is usable.
+type Options struct {
+	// Realm sent in the WWW-Authenticate header.
+	// Defaults to DefaultRealm if empty.
+	Realm string
+
+	// MaxConcurrent caps the number of verifications running at once.
+	// Requests over the limit wait for a free slot or until their context is done.
+	// Defaults to DefaultMaxConcurrent if unset(0), or to argon2password.MaxConcurrentHashes
+	// if the process memory limit allows fewer.
+	MaxConcurrent int
+
+	// Unauthorized is served when the credentials are missing or wrong.
+	// Defaults to a plain 401 response.
+	Unauthorized http.Handler
+
+	// CacheTTL enables the verified-credential cache when set.
+	// A credential that verified successfully is accepted again without
+	// running Argon2id until the TTL expires. Keep it short (seconds to a
+	// few minutes), a password change is only picked up once the stored
+	// hash changes or the entry expires.
+	// Disabled if unset(0).
+	CacheTTL time.Duration
+
+	// CacheSize caps the number of cached credentials.
+	// Defaults to DefaultCacheSize if unset(0).
+	CacheSize int
+}
+
+// Authenticator verifies Basic Auth credentials against a CredentialStore.
+// It is safe for concurrent use.
+type Authenticator struct {
+	store        CredentialStore
+	challenge    string
+	sem          chan struct{}
+	unauthorized http.Handler
+	cache        *verifiedCache
+}
+
+type contextKey struct{}
+
+// New returns an Authenticator for store. opts may be nil.
+func New(store CredentialStore, opts *Options) (*Authenticator, error) {
+	if store == nil {
+		return nil, ErrNilStore
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	realm := opts.Realm
+	if realm == "" {
+		realm = DefaultRealm
+	}
+
+	maxConcurrent := opts.MaxConcurrent
+	switch {
+	case maxConcurrent < 0:
+		return nil, ErrInvalidConcurrent
+	case maxConcurrent == 0:
+		maxConcurrent = DefaultMaxConcurrent
+		if n := argon2password.MaxConcurrentHashes(); n > 0 {
+			maxConcurrent = min(maxConcurrent, n)
+		}
+	}
+
+	var cache *verifiedCache
+	switch {
+	case opts.CacheTTL < 0 || opts.CacheSize < 0:
+		return nil, ErrInvalidCache
+	case opts.CacheTTL > 0:
+		size := opts.CacheSize
+		if size == 0 {
+			size = DefaultCacheSize
+		}
+		var err error
+		cache, err = newVerifiedCache(opts.CacheTTL, size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a := &Authenticator{
+		store:        store,
+		challenge:    `Basic realm="` + strings.ReplaceAll(realm, `"`, `\"`) + `", charset="UTF-8"`,
+		sem:          make(chan struct{}, maxConcurrent),
+		unauthorized: opts.Unauthorized,
+		cache:        cache,
+	}
+	return a, nil
+}
+
+// Authenticate reports whether password is correct for username.
+// Unknown users cost the same as known users with a wrong password.
+// A password the client sent over MaxPasswordLength is a failed login,
+// not an error. It blocks until a verification slot is free or ctx is done.
+func (a *Authenticator) Authenticate(ctx context.Context, username, password string) (bool, error) {
+	if password == "" {
+		return false, nil
+	}
+
+	hash, ok, err := a.store.LookupHash(ctx, username)
+	if err != nil {
+		return false, fmt.Errorf("argon2Password: credential lookup failed: %w", err)
+	}
+
+	// A credential verified moments ago skips Argon2id entirely
+	var key cacheKey
+	if ok && a.cache != nil {
+		key = a.cache.sum(username, password, hash)
+		if a.cache.contains(key) {
+			return true, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return false, err //nolint:wrapcheck // callers check for context errors
+	}
+	select {
+	case a.sem <- struct{}{}:
+	case <-ctx.Done():
+		return false, ctx.Err() //nolint:wrapcheck // callers check for context errors
+	}
+	defer func() { <-a.sem }()
+
+	// Unknown users are verified against a dummy hash so they cost the same
+	// as a known user with a wrong password
+	if !ok {
+		_, err := argon2password.CompareDummy(password)
+		if rejectedPassword(err) {
+			return false, nil
+		}
+		return false, err //nolint:wrapcheck // already wrapped by argon2password
+	}
+
+	match, err := argon2password.ComparePW(password, hash)
+	if rejectedPassword(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err //nolint:wrapcheck // already wrapped by argon2password
+	}
+	if !match {
+		return false, nil
+	}
+	if a.cache != nil {
+		a.cache.add(key)
+	}
+	return true, nil
+}
+
+// rejectedPassword reports whether err rejects the password itself, input
+// the client controls, rather than the hash or the server
+func rejectedPassword(err error) bool {
+	return errors.Is(err, argon2password.ErrPasswordTooLong) || errors.Is(err, argon2password.ErrEmptyPassword)
+}
+
+// Middleware wraps next so it is only reached with valid credentials.
+// The authenticated username is available through Username.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			a.deny(w, r)
+			return
+		}
+
+		match, err := a.Authenticate(r.Context(), username, password)
+		switch {
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		case err != nil:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		case !match:
+			a.deny(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, username)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (a *Authenticator) deny(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", a.challenge)
+	if a.unauthorized != nil {
+		a.unauthorized.ServeHTTP(w, r)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// Middleware is a shorthand for New(store, opts) followed by Authenticator.Middleware.
+func Middleware(store CredentialStore, opts *Options) (func(http.Handler) http.Handler, error) {
+	a, err := New(store, opts)
+	if err != nil {
+		return nil, err
+	}
+	return a.Middleware, nil
+}
+
+// Username returns the username authenticated by the middleware.
+func Username(ctx context.Context) (string, bool) {
+	username, ok := ctx.Value(contextKey{}).(string)
+	return username, ok
+}
@@ -0,0 +1,62 @@
+package basicauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"gopkg.hlmpn.dev/pkg/argon2password/internal/ttlcache"
+)
+
+// DefaultCacheSize is the default number of entries kept by the verified-credential cache.
+const DefaultCacheSize = 1024
+
+type cacheKey [sha256.Size]byte
+
+// verifiedCache remembers recently verified credentials for a short time.
+// Entries are keyed by an HMAC of username, password and stored hash using a
+// random per-process key, so the cache never holds a password or anything
+// that can be attacked offline, and any change to the stored hash misses.
+type verifiedCache struct {
+	key     []byte
+	entries *ttlcache.Cache[cacheKey, struct{}]
+}
+
+func newVerifiedCache(ttl time.Duration, size int) (*verifiedCache, error) {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to generate cache key: %w", err)
+	}
+	return &verifiedCache{
+		key:     key,
+		entries: ttlcache.New[cacheKey, struct{}](ttl, size),
+	}, nil
+}
+
+func (c *verifiedCache) sum(username, password, hash string) cacheKey {
+	mac := hmac.New(sha256.New, c.key)
+	// Length prefixes keep ("ab", "c") and ("a", "bc") apart
+	var length [8]byte
+	for _, part := range []string{username, password, hash} {
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		mac.Write(length[:])
+		mac.Write([]byte(part))
+	}
+	var k cacheKey
+	mac.Sum(k[:0])
+	return k
+}
+
+// contains reports whether the credential was verified within the TTL.
+func (c *verifiedCache) contains(k cacheKey) bool {
+	_, ok := c.entries.Get(k)
+	return ok
+}
+
+// add stores a verified credential, see ttlcache.Cache.Add for the eviction.
+func (c *verifiedCache) add(k cacheKey) {
+	c.entries.Add(k, struct{}{})
+}
@@ -0,0 +1,15 @@
+package basicauth
+
+import "errors"
+
+var (
+	ErrNilStore          = errors.New("argon2Password: Credential store is nil")
+	ErrInvalidConcurrent = errors.New("argon2Password: MaxConcurrent cannot be negative")
+	ErrInvalidCache      = errors.New("argon2Password: CacheTTL and CacheSize cannot be negative")
+)
+
+// htpasswd errors
+var (
+	ErrInvalidHtpasswd = errors.New("argon2Password: Invalid htpasswd line")
+	ErrUnsupportedHash = errors.New("argon2Password: Unsupported hash in htpasswd, only argon2id is supported")
+)
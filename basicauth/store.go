@@ -0,0 +1,112 @@
+package basicauth
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// CredentialStore looks up the stored argon2id hash for a username.
+// LookupHash returns the hash and true when the user exists, and false
+// when it does not. The error is reserved for failures of the store itself
+// (I/O, database errors, etc.), an unknown user is not an error.
+type CredentialStore interface {
+	LookupHash(ctx context.Context, username string) (string, bool, error)
+}
+
+// StoreFunc adapts a plain function to a CredentialStore.
+type StoreFunc func(ctx context.Context, username string) (string, bool, error)
+
+// LookupHash calls f(ctx, username).
+func (f StoreFunc) LookupHash(ctx context.Context, username string) (string, bool, error) {
+	return f(ctx, username)
+}
+
+// MapStore is an in-memory CredentialStore mapping usernames to argon2id hashes.
+// It must not be modified once it is used by an Authenticator.
+type MapStore map[string]string
+
+// LookupHash returns the hash stored for username.
+func (m MapStore) LookupHash(_ context.Context, username string) (string, bool, error) {
+	hash, ok := m[username]
+	return hash, ok, nil
+}
+
+// ParseHtpasswd reads htpasswd formatted "user:hash" lines from r.
+// Blank lines and lines starting with '#' are ignored.
+// Only argon2id hashes, plain or wrapped, are accepted, any other scheme is reported as an error
+// so a mixed file is caught at load time instead of failing logins later.
+func ParseHtpasswd(r io.Reader) (MapStore, error) {
+	users := make(MapStore)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		username, hash, found := strings.Cut(text, ":")
+		switch {
+		case !found || username == "":
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidHtpasswd, line)
+		case !strings.HasPrefix(hash, argon2idPrefix) && !argon2password.IsWrappedHash(hash):
+			return nil, fmt.Errorf("%w: line %d: user %q", ErrUnsupportedHash, line, username)
+		}
+		users[username] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to read htpasswd: %w", err)
+	}
+	return users, nil
+}
+
+// HtpasswdFile is a CredentialStore backed by an htpasswd file on disk.
+// The file is read once on load, call Reload to pick up changes.
+type HtpasswdFile struct {
+	path  string
+	mu    sync.RWMutex
+	users MapStore
+}
+
+// LoadHtpasswd reads the htpasswd file at path.
+func LoadHtpasswd(path string) (*HtpasswdFile, error) {
+	h := &HtpasswdFile{path: filepath.Clean(path)}
+	if err := h.Reload(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Reload re-reads the file. On error the previously loaded users are kept.
+func (h *HtpasswdFile) Reload() error {
+	f, err := os.Open(h.path)
+	if err != nil {
+		return fmt.Errorf("argon2Password: failed to open htpasswd: %w", err)
+	}
+	defer f.Close()
+
+	users, err := ParseHtpasswd(f)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.users = users
+	h.mu.Unlock()
+	return nil
+}
+
+// LookupHash returns the hash stored for username.
+func (h *HtpasswdFile) LookupHash(ctx context.Context, username string) (string, bool, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.users.LookupHash(ctx, username)
+}
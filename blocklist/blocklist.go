@@ -0,0 +1,49 @@
+// Package blocklist rejects common passwords with a compact Bloom filter,
+// for deployments where a full breached password corpus is too large.
+//
+// Common returns the filter embedded in the package, built from common_passwords.txt
+// of the argon2password package, the built-in blocklist of NISTPolicy: the 1,751
+// passwords of 8 or more characters among the 7,141 of the zxcvbn frequency list,
+// which ranks Mark Burnett's 10,000 top passwords, taken from zxcvbn-go v1.0.4
+// on 2026-10-18. common_passwords.LICENSE has the source and its MIT licence.
+// At CommonFalsePositiveRate, 1 in 10,000, the filter takes 4.2 KB.
+// Filters for custom word lists are built with FromWords, or with
+// "argon2password blocklist" and loaded with Load:
+//
+//	policy := argon2password.NISTPolicy{Checkers: []argon2password.PasswordChecker{blocklist.Common()}}
+//
+// To embed a larger list, e.g. the top 100,000 passwords of a breach corpus
+// filtered to 8 or more characters, replace common_passwords.txt and run go generate.
+// At CommonFalsePositiveRate the filter takes about 19 bits per word, 240 KB
+// for 100,000 words.
+package blocklist
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+)
+
+// CommonFalsePositiveRate is the false positive rate the embedded filter is built for.
+const CommonFalsePositiveRate = 0.0001
+
+// common.bf holds the 1,751 words of common_passwords.txt, see the package doc for their source
+//go:generate go run ../cmd/argon2password blocklist -fp 0.0001 -o common.bf ../common_passwords.txt
+
+//go:embed common.bf
+var commonFilter []byte
+
+// common decodes the embedded filter once
+var common = sync.OnceValue(func() *Filter {
+	f, err := Load(commonFilter)
+	if err != nil {
+		panic(fmt.Sprintf("argon2password/blocklist: embedded filter: %v", err))
+	}
+	return f
+})
+
+// Common returns the embedded filter of common passwords. It is shared,
+// don't Add to it.
+func Common() *Filter {
+	return common()
+}
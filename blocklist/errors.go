@@ -0,0 +1,8 @@
+package blocklist
+
+import "errors"
+
+var (
+	ErrInvalidParams = errors.New("argon2Password: Invalid filter size or false positive rate")
+	ErrInvalidFilter = errors.New("argon2Password: Invalid blocklist filter")
+)
@@ -0,0 +1,173 @@
+package blocklist
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// Binary filter format: filterMagic, the number of bits, the number of hash
+// functions and the number of words as big-endian integers, then the bits
+var filterMagic = []byte("A2PWBLM1")
+
+const filterHeaderSize = 8 + 8 + 4 + 8
+
+// Bounds of the filter parameters, a word list past them is a mistake
+const (
+	maxHashFunctions = 32
+	maxFilterBits    = 1 << 36 // 8 GiB
+)
+
+// maxWordLength bounds the lines of a word list
+const maxWordLength = 1024
+
+// Filter is a Bloom filter of passwords. Contains never misses a password that
+// was added, and wrongly reports other passwords at the false positive rate
+// the filter was built for. Passwords are compared case-insensitively after
+// NFKC normalization, like NISTPolicy.Blocklist.
+//
+// A Filter is safe for concurrent Contains and Check calls, Add must not
+// run at the same time.
+type Filter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint32 // number of hash functions
+	n    uint64 // number of words added
+}
+
+// New returns an empty Filter sized for n words at falsePositiveRate, e.g. 0.001.
+func New(n int, falsePositiveRate float64) (*Filter, error) {
+	if n < 0 || !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+		return nil, ErrInvalidParams
+	}
+	// Optimal sizes: m = -n ln(p) / ln(2)^2 bits and k = m/n ln(2) hash functions
+	words := float64(max(n, 1))
+	m := math.Ceil(-words * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / words * math.Ln2)
+	if m > maxFilterBits {
+		return nil, ErrInvalidParams
+	}
+	return newFilter(uint64(m), uint32(min(max(k, 1), maxHashFunctions))), nil
+}
+
+func newFilter(m uint64, k uint32) *Filter {
+	m = max(m, 64)                                              //nolint:mnd // one word
+	return &Filter{bits: make([]uint64, (m+63)/64), m: m, k: k} //nolint:mnd // bits per word
+}
+
+// FromWords builds a Filter from a word list read from r, one password per line.
+// Blank lines are skipped and lines are used as is, without trimming spaces.
+func FromWords(r io.Reader, falsePositiveRate float64) (*Filter, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, maxWordLength), maxWordLength)
+	for scanner.Scan() {
+		word := strings.TrimSuffix(scanner.Text(), "\r")
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to read word list: %w", err)
+	}
+	f, err := New(len(words), falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range words {
+		f.Add(word)
+	}
+	return f, nil
+}
+
+// Add adds password to the filter.
+func (f *Filter) Add(password string) {
+	h1, h2 := filterHashes(password)
+	for i := range uint64(f.k) {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.n++
+}
+
+// Contains reports whether password was probably added to the filter.
+func (f *Filter) Contains(password string) bool {
+	h1, h2 := filterHashes(password)
+	for i := range uint64(f.k) {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of words added to the filter.
+func (f *Filter) Len() int {
+	return int(f.n) //nolint:gosec // G115, a filter can't hold more words than fit in memory
+}
+
+// Check reports an argon2password.ViolationBlocklisted when password is in the filter.
+// It implements argon2password.PasswordChecker, the error is always nil.
+func (f *Filter) Check(_ context.Context, password string) ([]argon2password.Violation, error) {
+	if !f.Contains(password) {
+		return nil, nil
+	}
+	return []argon2password.Violation{{
+		Code:    argon2password.ViolationBlocklisted,
+		Message: "Password is too common",
+	}}, nil
+}
+
+// MarshalBinary encodes the filter in the format read by Load.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, filterHeaderSize+len(f.bits)*8)
+	data = append(data, filterMagic...)
+	data = binary.BigEndian.AppendUint64(data, f.m)
+	data = binary.BigEndian.AppendUint32(data, f.k)
+	data = binary.BigEndian.AppendUint64(data, f.n)
+	for _, word := range f.bits {
+		data = binary.BigEndian.AppendUint64(data, word)
+	}
+	return data, nil
+}
+
+// Load decodes a filter encoded by MarshalBinary.
+func Load(data []byte) (*Filter, error) {
+	if len(data) < filterHeaderSize || string(data[:len(filterMagic)]) != string(filterMagic) {
+		return nil, ErrInvalidFilter
+	}
+	header := data[len(filterMagic):filterHeaderSize]
+	m := binary.BigEndian.Uint64(header)
+	k := binary.BigEndian.Uint32(header[8:])
+	n := binary.BigEndian.Uint64(header[12:])
+	if m < 64 || m > maxFilterBits || k == 0 || k > maxHashFunctions {
+		return nil, ErrInvalidFilter
+	}
+	body := data[filterHeaderSize:]
+	if uint64(len(body)) != (m+63)/64*8 {
+		return nil, ErrInvalidFilter
+	}
+	f := newFilter(m, k)
+	f.n = n
+	for i := range f.bits {
+		f.bits[i] = binary.BigEndian.Uint64(body[i*8:])
+	}
+	return f, nil
+}
+
+// filterHashes returns the two hashes combined into the k bit positions,
+// h2 is odd so the positions don't repeat for a power of two number of bits
+func filterHashes(password string) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(strings.ToLower(norm.NFKC.String(password))))
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16]) | 1
+}
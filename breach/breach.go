@@ -0,0 +1,119 @@
+// Package breach checks passwords against a local copy of the Have I Been Pwned
+// Pwned Passwords SHA-1 list, without any network access.
+//
+// A List is opened from either the text dump, one "HASH:COUNT" line per password
+// sorted by hash as written by the PwnedPasswordsDownloader, or from a compact
+// binary index built from it with BuildIndex or "argon2password breach".
+// Both are memory-mapped and searched with a binary search, so opening is
+// instant and only the pages touched by lookups are read from disk.
+//
+//	list, err := breach.Open("/var/lib/pwned/pwned-passwords.idx")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer list.Close()
+//	policy := argon2password.NISTPolicy{Checkers: []argon2password.PasswordChecker{list}}
+//
+// Services that can make outbound calls can use a Client for the range API
+// instead, which only ever sends the first 5 hex characters of the SHA-1 hash.
+package breach
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // the Pwned Passwords list is keyed by SHA-1
+	"fmt"
+	"os"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// ViolationBreached is reported for passwords found in a breached password list.
+const ViolationBreached argon2password.ViolationCode = "breached"
+
+// List is a memory-mapped breached password list. It is safe for concurrent
+// lookups, Close must not be called while lookups run.
+type List struct {
+	// MinCount is the number of breaches a password needs to be reported by
+	// Check, passwords seen fewer times pass. Defaults to 1 if unset(0).
+	MinCount uint32
+
+	data  []byte // the whole file
+	index bool   // binary index rather than text dump
+}
+
+// Open maps the text dump or binary index at path, the format is detected from its header.
+func Open(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to open breached password list: %w", err)
+	}
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+	l := &List{data: data, index: bytes.HasPrefix(data, indexMagic)}
+	if l.index && (len(data)-len(indexMagic))%indexRecordSize != 0 {
+		_ = unmapFile(data)
+		return nil, fmt.Errorf("%w: truncated index", ErrInvalidList)
+	}
+	return l, nil
+}
+
+// Close unmaps the list.
+func (l *List) Close() error {
+	data := l.data
+	l.data = nil
+	if data == nil {
+		return nil
+	}
+	return unmapFile(data)
+}
+
+// Lookup returns how many times the password with the given SHA-1 hash was
+// seen in breaches, and whether it is in the list. Lines of a text dump
+// without a count are counted once.
+func (l *List) Lookup(hash [sha1.Size]byte) (uint32, bool, error) {
+	if l.data == nil {
+		return 0, false, ErrListClosed
+	}
+	if l.index {
+		count, found := lookupIndex(l.data[len(indexMagic):], hash)
+		return count, found, nil
+	}
+	return lookupText(l.data, hash)
+}
+
+// LookupPassword is Lookup for the SHA-1 hash of password.
+func (l *List) LookupPassword(password string) (uint32, bool, error) {
+	return l.Lookup(sha1.Sum([]byte(password))) //nolint:gosec // the Pwned Passwords list is keyed by SHA-1
+}
+
+// Check reports a ViolationBreached when password was seen in at least MinCount breaches.
+// It implements argon2password.PasswordChecker.
+func (l *List) Check(_ context.Context, password string) ([]argon2password.Violation, error) {
+	count, found, err := l.LookupPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return breachedViolation(count, found, l.MinCount), nil
+}
+
+// breachedViolation returns the violation for a password seen count times, if any
+func breachedViolation(count uint32, found bool, minCount uint32) []argon2password.Violation {
+	if !found || count < max(minCount, 1) {
+		return nil
+	}
+	return []argon2password.Violation{{
+		Code:     ViolationBreached,
+		Message:  "Password appeared in a data breach, choose a different one",
+		Required: int(max(minCount, 1)),
+		Actual:   int(count),
+	}}
+}
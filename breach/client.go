@@ -0,0 +1,206 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // the Pwned Passwords API is keyed by SHA-1
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+	"gopkg.hlmpn.dev/pkg/argon2password/internal/ttlcache"
+)
+
+const (
+	// DefaultBaseURL is the Pwned Passwords API used when ClientOptions.BaseURL is empty.
+	DefaultBaseURL = "https://api.pwnedpasswords.com"
+
+	// DefaultTimeout bounds a range request when ClientOptions.HTTPClient is nil.
+	DefaultTimeout = 5 * time.Second
+
+	// DefaultCacheSize is the default number of range responses kept by the cache.
+	DefaultCacheSize = 1024
+
+	// DefaultUserAgent is sent when ClientOptions.UserAgent is empty, the API rejects requests without one.
+	DefaultUserAgent = "gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// rangePrefixLength is the number of hex characters of the SHA-1 hash sent to the API
+const rangePrefixLength = 5
+
+// maxRangeResponse bounds the size of a range response, a padded one is well under 100 KiB
+const maxRangeResponse = 4 << 20
+
+// ClientOptions configures a Client. The zero value is usable.
+type ClientOptions struct {
+	// BaseURL of the API, e.g. an httptest server or an internal mirror.
+	// Defaults to DefaultBaseURL if empty.
+	BaseURL string
+
+	// HTTPClient sends the range requests, e.g. with a custom Transport or proxy.
+	// Defaults to a client with DefaultTimeout if nil.
+	HTTPClient *http.Client
+
+	// UserAgent sent with each request. Defaults to DefaultUserAgent if empty.
+	UserAgent string
+
+	// Padding asks the API to pad responses with fake entries, so the size of a
+	// response doesn't tell an observer which prefix was requested.
+	Padding bool
+
+	// CacheTTL enables the range response cache when set. Responses are the
+	// same for all passwords sharing a prefix and change rarely, hours are fine.
+	// Disabled if unset(0).
+	CacheTTL time.Duration
+
+	// CacheSize caps the number of cached range responses.
+	// Defaults to DefaultCacheSize if unset(0).
+	CacheSize int
+
+	// MinCount is the number of breaches a password needs to be reported by
+	// Check, passwords seen fewer times pass. Defaults to 1 if unset(0).
+	MinCount uint32
+}
+
+// Client checks passwords against the Pwned Passwords range API using k-anonymity:
+// only the first 5 hex characters of the SHA-1 hash of a password are sent,
+// and the matching suffixes are compared locally. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+	padding    bool
+	minCount   uint32
+	// cache remembers range responses by prefix. It only holds hash prefixes
+	// and the public suffix lists, never a password or its full hash. Nil if disabled.
+	cache *ttlcache.Cache[string, map[string]uint32]
+}
+
+// NewClient returns a Client for the range API. opts may be nil.
+func NewClient(opts *ClientOptions) (*Client, error) {
+	if opts == nil {
+		opts = &ClientOptions{}
+	}
+	c := &Client{
+		baseURL:    strings.TrimSuffix(opts.BaseURL, "/"),
+		httpClient: opts.HTTPClient,
+		userAgent:  opts.UserAgent,
+		padding:    opts.Padding,
+		minCount:   opts.MinCount,
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	if c.userAgent == "" {
+		c.userAgent = DefaultUserAgent
+	}
+
+	switch {
+	case opts.CacheTTL < 0 || opts.CacheSize < 0:
+		return nil, ErrInvalidCache
+	case opts.CacheTTL > 0:
+		size := opts.CacheSize
+		if size == 0 {
+			size = DefaultCacheSize
+		}
+		c.cache = ttlcache.New[string, map[string]uint32](opts.CacheTTL, size)
+	}
+	return c, nil
+}
+
+// Lookup returns how many times the password with the given SHA-1 hash was
+// seen in breaches, and whether it was.
+func (c *Client) Lookup(ctx context.Context, hash [sha1.Size]byte) (uint32, bool, error) {
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+	prefix, suffix := hexHash[:rangePrefixLength], hexHash[rangePrefixLength:]
+
+	counts, ok := c.cache.Get(prefix)
+	if !ok {
+		var err error
+		counts, err = c.fetchRange(ctx, prefix)
+		if err != nil {
+			return 0, false, err
+		}
+		c.cache.Add(prefix, counts)
+	}
+	count, found := counts[suffix]
+	return count, found, nil
+}
+
+// LookupPassword is Lookup for the SHA-1 hash of password.
+func (c *Client) LookupPassword(ctx context.Context, password string) (uint32, bool, error) {
+	return c.Lookup(ctx, sha1.Sum([]byte(password))) //nolint:gosec // the Pwned Passwords API is keyed by SHA-1
+}
+
+// Check reports a ViolationBreached when password was seen in at least MinCount breaches.
+// It implements argon2password.PasswordChecker.
+func (c *Client) Check(ctx context.Context, password string) ([]argon2password.Violation, error) {
+	count, found, err := c.LookupPassword(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+	return breachedViolation(count, found, c.minCount), nil
+}
+
+// fetchRange requests the suffixes of prefix and returns their counts, without padding entries
+func (c *Client) fetchRange(ctx context.Context, prefix string) (map[string]uint32, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to create range request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.padding {
+		req.Header.Set("Add-Padding", "true")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("argon2Password: range request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrRangeStatus, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRangeResponse))
+	if err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to read range response: %w", err)
+	}
+	return parseRange(body)
+}
+
+// parseRange parses the "SUFFIX:COUNT" lines of a range response
+func parseRange(body []byte) (map[string]uint32, error) {
+	counts := make(map[string]uint32)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		suffix, countText, ok := strings.Cut(text, ":")
+		if !ok || len(suffix) != hex.EncodedLen(sha1.Size)-rangePrefixLength {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidRange, line)
+		}
+		count, err := strconv.ParseUint(countText, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidRange, line)
+		}
+		// Padding entries have a count of 0
+		if count > 0 {
+			counts[strings.ToUpper(suffix)] = uint32(count)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRange, err)
+	}
+	return counts, nil
+}
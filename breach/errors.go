@@ -0,0 +1,17 @@
+package breach
+
+import "errors"
+
+// Breached password list errors
+var (
+	ErrInvalidList = errors.New("argon2Password: Invalid breached password list")
+	ErrUnsorted    = errors.New("argon2Password: Breached password list is not sorted by hash")
+	ErrListClosed  = errors.New("argon2Password: Breached password list is closed")
+)
+
+// Range API client errors
+var (
+	ErrInvalidCache = errors.New("argon2Password: CacheTTL and CacheSize cannot be negative")
+	ErrRangeStatus  = errors.New("argon2Password: Unexpected range API response status")
+	ErrInvalidRange = errors.New("argon2Password: Invalid range API response")
+)
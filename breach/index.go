@@ -0,0 +1,86 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // the Pwned Passwords list is keyed by SHA-1
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Binary index format: indexMagic followed by records of the SHA-1 hash and
+// the breach count as a big-endian uint32, sorted by hash
+var indexMagic = []byte("A2PWBRI1")
+
+const indexRecordSize = sha1.Size + 4
+
+// maxLineLength bounds the lines of a text dump, a hash and a count fit in far less
+const maxLineLength = 256
+
+// BuildIndex converts a text dump read from r, one "HASH:COUNT" line per password
+// sorted by hash, into a binary index written to w, and returns the number of
+// passwords written. Passwords seen fewer than minCount times are left out to
+// make the index smaller. The index stores 24 bytes per password, less than
+// half of the text dump.
+func BuildIndex(w io.Writer, r io.Reader, minCount uint32) (int, error) {
+	out := bufio.NewWriter(w)
+	if _, err := out.Write(indexMagic); err != nil {
+		return 0, fmt.Errorf("argon2Password: failed to write index: %w", err)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, maxLineLength), maxLineLength)
+	var (
+		previous [sha1.Size]byte
+		seen     bool
+		record   [indexRecordSize]byte
+		written  int
+	)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		hash, count, err := parseLine(scanner.Bytes())
+		if err != nil {
+			return written, fmt.Errorf("%w: line %d", err, line)
+		}
+		if seen && bytes.Compare(hash[:], previous[:]) <= 0 {
+			return written, fmt.Errorf("%w: line %d", ErrUnsorted, line)
+		}
+		previous, seen = hash, true
+		if count < minCount {
+			continue
+		}
+		copy(record[:], hash[:])
+		binary.BigEndian.PutUint32(record[sha1.Size:], count)
+		if _, err := out.Write(record[:]); err != nil {
+			return written, fmt.Errorf("argon2Password: failed to write index: %w", err)
+		}
+		written++
+	}
+	if err := scanner.Err(); err != nil {
+		return written, fmt.Errorf("argon2Password: failed to read breached password list: %w", err)
+	}
+	if err := out.Flush(); err != nil {
+		return written, fmt.Errorf("argon2Password: failed to write index: %w", err)
+	}
+	return written, nil
+}
+
+// lookupIndex binary searches the records of a binary index
+func lookupIndex(records []byte, hash [sha1.Size]byte) (uint32, bool) {
+	n := len(records) / indexRecordSize
+	i := sort.Search(n, func(i int) bool {
+		return bytes.Compare(records[i*indexRecordSize:i*indexRecordSize+sha1.Size], hash[:]) >= 0
+	})
+	if i == n {
+		return 0, false
+	}
+	record := records[i*indexRecordSize : (i+1)*indexRecordSize]
+	if !bytes.Equal(record[:sha1.Size], hash[:]) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(record[sha1.Size:]), true
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package breach
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// mapFile reads f into memory where it can't be mapped
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to read breached password list: %w", err)
+	}
+	return data, nil
+}
+
+// unmapFile is a no-op, the memory is released by the garbage collector
+func unmapFile([]byte) error {
+	return nil
+}
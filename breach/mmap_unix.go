@@ -0,0 +1,32 @@
+//go:build unix
+
+package breach
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapFile maps f read-only, the pages are loaded by the kernel as lookups touch them
+func mapFile(f *os.File, size int) ([]byte, error) {
+	if size == 0 {
+		return []byte{}, nil
+	}
+	data, err := unix.Mmap(int(f.Fd()), 0, size, unix.PROT_READ, unix.MAP_SHARED) //nolint:gosec // G115, file descriptors fit in an int
+	if err != nil {
+		return nil, fmt.Errorf("argon2Password: failed to map breached password list: %w", err)
+	}
+	// Lookups jump around the file, read-ahead would load pages for nothing
+	_ = unix.Madvise(data, unix.MADV_RANDOM)
+	return data, nil
+}
+
+// unmapFile unmaps memory from mapFile
+func unmapFile(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return unix.Munmap(data) //nolint:wrapcheck // only fails for invalid mappings
+}
@@ -0,0 +1,66 @@
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // the Pwned Passwords list is keyed by SHA-1
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// lookupText binary searches a text dump sorted by hash. The search works on
+// byte offsets, each probe backs up to the start of its line.
+func lookupText(data []byte, hash [sha1.Size]byte) (uint32, bool, error) {
+	lo, hi := 0, len(data) // both at the start of a line
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start := lo + bytes.LastIndexByte(data[lo:mid], '\n') + 1
+		end := bytes.IndexByte(data[start:hi], '\n')
+		if end < 0 {
+			end = hi
+		} else {
+			end += start
+		}
+		line := data[start:end]
+		if len(bytes.TrimSpace(line)) == 0 {
+			// Blank lines only appear at the end of a dump
+			hi = start
+			continue
+		}
+		lineHash, count, err := parseLine(line)
+		if err != nil {
+			return 0, false, fmt.Errorf("%w: at offset %d", err, start)
+		}
+		switch c := bytes.Compare(hash[:], lineHash[:]); {
+		case c == 0:
+			return count, true, nil
+		case c < 0:
+			hi = start
+		default:
+			lo = end + 1
+		}
+	}
+	return 0, false, nil
+}
+
+// parseLine parses a "HASH:COUNT" line, a line without a count is counted once
+func parseLine(line []byte) ([sha1.Size]byte, uint32, error) {
+	var hash [sha1.Size]byte
+	line = bytes.TrimSpace(line)
+	hexHash, countBytes, hasCount := bytes.Cut(line, []byte(":"))
+	if len(hexHash) != hex.EncodedLen(sha1.Size) {
+		return hash, 0, ErrInvalidList
+	}
+	if _, err := hex.Decode(hash[:], hexHash); err != nil {
+		return hash, 0, ErrInvalidList
+	}
+	if !hasCount {
+		return hash, 1, nil
+	}
+	count, err := strconv.ParseUint(string(countBytes), 10, 64)
+	if err != nil {
+		return hash, 0, ErrInvalidList
+	}
+	return hash, uint32(min(count, math.MaxUint32)), nil
+}
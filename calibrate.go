@@ -0,0 +1,114 @@
+package argon2password
+
+import (
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Calibration parameters
+const (
+	calibrationRuns       = 3       // measurements per candidate, the median is used
+	calibrationMinMemory  = 7 * MiB // lowest OWASP configuration
+	calibrationMemoryStep = MiB     // memory is calibrated in whole MiB
+)
+
+// calibrationPassword is hashed while calibrating, the value doesn't matter
+var calibrationPassword = []byte("argon2password-calibration")
+
+// Calibrate benchmarks Argon2id on the current machine and returns the
+// highest-cost Config whose hashing time stays within target.
+//
+// maxMemory is the memory budget per hash, e.g. 256 * MiB.
+// It defaults to ArgonMaxMemory if unset(0) and is capped at ArgonMaxMemory,
+// so the resulting hashes can be verified with ComparePW.
+//
+// As advised by RFC 9106, memory is maximized first with a single pass,
+// and the number of iterations is only raised once the memory budget is used up.
+// Parallelism is the default parallelism of the machine.
+// An error is returned if no configuration meeting the OWASP minimum fits within target.
+//
+// Calibration runs several full Argon2id computations and takes about
+// ten times target, so it is meant to be run once at startup or from a tool.
+func Calibrate(target time.Duration, maxMemory MemorySize) (*Config, error) {
+	if target <= 0 {
+		return nil, ErrCalibrationTarget
+	}
+	if maxMemory == 0 || maxMemory > ArgonMaxMemory {
+		maxMemory = ArgonMaxMemory
+	}
+	if maxMemory < calibrationMinMemory {
+		return nil, ErrCalibrationMaxMemory
+	}
+
+	parallelism := argonDefaultParallelism
+
+	// Largest memory that fits in the target with a single pass.
+	// Time scales roughly linearly with memory, so scale down from the
+	// measured time instead of probing every step.
+	memory := maxMemory / calibrationMemoryStep * calibrationMemoryStep
+	elapsed := measureArgon(memory, 1, parallelism)
+	for elapsed > target && memory > calibrationMinMemory {
+		next := MemorySize(float64(memory)*float64(target)/float64(elapsed)) / calibrationMemoryStep * calibrationMemoryStep
+		if next >= memory {
+			next = memory - calibrationMemoryStep
+		}
+		memory = max(next, calibrationMinMemory)
+		elapsed = measureArgon(memory, 1, parallelism)
+	}
+	if elapsed > target {
+		return nil, ErrCalibrationTooSlow
+	}
+
+	// Spend the remaining time on iterations, time scales linearly with them
+	iterations := uint32(1)
+	if estimate := uint32(target / max(elapsed, 1)); estimate > 1 { //nolint:gosec // G115, target/elapsed >= 1 here
+		iterations = min(estimate, ArgonMaxIterations)
+		for iterations > 1 && measureArgon(memory, iterations, parallelism) > target {
+			iterations--
+		}
+	}
+
+	if !MeetsOWASPMinimum(memory, iterations) {
+		return nil, ErrCalibrationTooSlow
+	}
+
+	config := &Config{
+		MaxMemory:     ArgonMaxMemory,
+		MaxIterations: ArgonMaxIterations,
+		Memory:        memory,
+		Iterations:    iterations,
+		SaltLength:    ArgonSaltLength,
+		KeyLength:     ArgonKeyLength,
+		Parallelism:   parallelism,
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// measureArgon returns the median time of a few Argon2id computations with the given parameters
+func measureArgon(memory MemorySize, iterations uint32, parallelism uint8) time.Duration {
+	salt := make([]byte, ArgonSaltLength)
+	durations := make([]time.Duration, calibrationRuns)
+	for i := range durations {
+		start := time.Now()
+		argon2.IDKey(calibrationPassword, salt, iterations, memory.KiB(), parallelism, ArgonKeyLength)
+		durations[i] = time.Since(start)
+	}
+	slices.Sort(durations)
+	return durations[len(durations)/2]
+}
+
+// MeetsOWASPMinimum reports whether memory and iterations are at least
+// as strong as one of the OWASP recommended configurations, see the OWASP presets.
+func MeetsOWASPMinimum(memory MemorySize, iterations uint32) bool {
+	for _, minimum := range owaspPresets {
+		if memory >= minimum.config.Memory && iterations >= minimum.config.Iterations {
+			return true
+		}
+	}
+	return false
+}
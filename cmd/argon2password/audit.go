@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// histogramWidth is the width of the longest bar in the audit histogram
+const histogramWidth = 40
+
+func runAudit(e *env, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	csvColumn := fs.Int("csv-column", 0, "1-based CSV column holding the hash, 0 reads one hash per line")
+	idColumn := fs.Int("id-column", 0, "1-based CSV column with an identifier to include in the report")
+	comma := fs.String("comma", ",", "CSV field separator")
+	header := fs.Bool("header", false, "skip the first line or record")
+	asJSON := fs.Bool("json", false, "print the full report as JSON instead of the summary")
+	memory := fs.Uint("m", uint(argon2password.ArgonMemory), "reference memory in KiB, lower is weak")
+	iterations := fs.Uint("t", uint(argon2password.ArgonIterations), "reference iterations, lower is weak")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password audit [flags] [FILE]")
+		fmt.Fprintln(e.stderr, "Classifies stored hashes read from FILE or stdin as argon2id current, argon2id weak,")
+		fmt.Fprintln(e.stderr, "wrapped, legacy, malformed or DoS-risky, and prints a summary or a JSON report.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() > 1 || len([]rune(*comma)) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if *memory > maxUint32 || *iterations > maxUint32 {
+		return errFlagRange
+	}
+
+	input, closeInput, err := openInput(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	opts := &argon2password.AuditOptions{
+		Reference: &argon2password.Config{
+			Memory:     argon2password.MemorySize(*memory),
+			Iterations: uint32(*iterations),
+		},
+		CSVColumn:   *csvColumn,
+		IDColumn:    *idColumn,
+		Comma:       []rune(*comma)[0],
+		SkipHeader:  *header,
+		SummaryOnly: !*asJSON,
+	}
+	report, err := argon2password.Audit(input, opts)
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped by argon2password
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)  // reasons contain '<'
+		return enc.Encode(report) //nolint:wrapcheck // encoding to stdout
+	}
+	writeHistogram(e.stdout, report)
+	return nil
+}
+
+// openInput opens path, or returns stdin for "" and "-"
+func openInput(e *env, path string) (io.Reader, func(), error) {
+	if path == "" || path == "-" {
+		return e.stdin, func() {}, nil
+	}
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck // the error includes the path
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+func writeHistogram(w io.Writer, report *argon2password.AuditReport) {
+	largest := 0
+	for _, count := range report.Counts {
+		largest = max(largest, count)
+	}
+
+	fmt.Fprintf(w, "%d hashes\n\n", report.Total)
+	for _, class := range argon2password.AuditClasses {
+		count := report.Counts[class]
+		bar, pct := 0, 0.0
+		if largest > 0 {
+			bar = count * histogramWidth / largest
+			pct = float64(count) * 100 / float64(report.Total) //nolint:mnd // percentage
+		}
+		fmt.Fprintf(w, "%-18s %8d %5.1f%% %s\n", class, count, pct, strings.Repeat("#", bar))
+	}
+
+	if len(report.Schemes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nBy scheme:")
+	for _, scheme := range sortedKeys(report.Schemes) {
+		fmt.Fprintf(w, "  %-22s %8d\n", scheme, report.Schemes[scheme])
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.hlmpn.dev/pkg/argon2password/blocklist"
+)
+
+func runBlocklist(e *env, args []string) error {
+	fs := flag.NewFlagSet("blocklist", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	output := fs.String("o", "", "write the filter to FILE instead of stdout")
+	falsePositiveRate := fs.Float64("fp", blocklist.CommonFalsePositiveRate, "false positive rate of the filter")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password blocklist [flags] [FILE]")
+		fmt.Fprintln(e.stderr, "Builds the Bloom filter loaded by blocklist.Load from a word list read from FILE")
+		fmt.Fprintln(e.stderr, "or stdin, one password per line.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if *output == "" {
+		if f, ok := e.stdout.(*os.File); ok && isTerminal(f) {
+			return errBinaryTerminal
+		}
+	}
+
+	input, closeInput, err := openInput(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	filter, err := blocklist.FromWords(input, *falsePositiveRate)
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped by blocklist
+	}
+	data, err := filter.MarshalBinary()
+	if err != nil {
+		return err //nolint:wrapcheck // encoding to memory
+	}
+	if *output != "" {
+		if err := os.WriteFile(filepath.Clean(*output), data, 0o644); err != nil { //nolint:gosec // G306, the filter is not secret
+			return err //nolint:wrapcheck // the error includes the path
+		}
+	} else if _, err := e.stdout.Write(data); err != nil {
+		return err //nolint:wrapcheck // writing to stdout
+	}
+	fmt.Fprintf(e.stderr, "%d words, %d bytes\n", filter.Len(), len(data))
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.hlmpn.dev/pkg/argon2password/breach"
+)
+
+var errBinaryTerminal = errors.New("refusing to write binary output to a terminal, use -o or redirect stdout")
+
+func runBreach(e *env, args []string) error {
+	fs := flag.NewFlagSet("breach", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	output := fs.String("o", "", "write the index to FILE instead of stdout")
+	minCount := fs.Uint("min-count", 1, "leave out passwords seen fewer times")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password breach [flags] [FILE]")
+		fmt.Fprintln(e.stderr, "Converts a Pwned Passwords SHA-1 dump read from FILE or stdin, one HASH:COUNT line")
+		fmt.Fprintln(e.stderr, "per password sorted by hash, into the binary index opened by breach.Open.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if *minCount > maxUint32 {
+		return fmt.Errorf("%w: -min-count=%d", errFlagRange, *minCount)
+	}
+
+	input, closeInput, err := openInput(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	var (
+		w    io.Writer = e.stdout
+		file *os.File
+	)
+	if *output != "" {
+		file, err = os.Create(filepath.Clean(*output))
+		if err != nil {
+			return err //nolint:wrapcheck // the error includes the path
+		}
+		w = file
+	} else if f, ok := e.stdout.(*os.File); ok && isTerminal(f) {
+		return errBinaryTerminal
+	}
+
+	n, err := breach.BuildIndex(w, input, uint32(*minCount)) //nolint:gosec // G115, checked above
+	if file != nil {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped by breach, or includes the path
+	}
+	fmt.Fprintf(e.stderr, "indexed %d passwords\n", n)
+	return nil
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+var (
+	errNoCandidate   = errors.New("no measured configuration meets the target, try a higher -target or smaller -m values")
+	errInvalidFormat = errors.New("invalid -format, must be one of text, go, json or env")
+	errInvalidGrid   = errors.New("invalid grid value")
+)
+
+// rowFormat lays out the measurement table
+const rowFormat = "%9s %3s %3s %9s %9s %9s %10s\n"
+
+// calibrationResult holds the measurements of one grid point
+type calibrationResult struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	p50         time.Duration
+	p95         time.Duration
+	p99         time.Duration
+	peakRSS     uint64 // bytes, 0 if unknown
+	skipped     bool   // the first sample was already far over the target
+}
+
+func runCalibrate(e *env, args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	target := fs.Duration("target", 250*time.Millisecond, "p95 latency target per hash")
+	concurrency := fs.Int("concurrency", 1, "number of hashes computed at the same time while measuring")
+	memoryBudget := fs.Uint("budget", 0, "total memory in KiB available to concurrent hashes, 0 for no limit")
+	memories := fs.String("m", "19456,47104,65536,131072", "comma separated memory values in KiB")
+	iterations := fs.String("t", "1,2,3", "comma separated iteration counts")
+	parallelisms := fs.String("p", "1,2,4", "comma separated parallelism values")
+	runs := fs.Int("runs", 5, "hashes per goroutine for each grid point")
+	format := fs.String("format", "text", "output format of the recommendation: text, go, json or env")
+	envPrefix := fs.String("env-prefix", "ARGON2_", "prefix of the variables printed with -format env")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password calibrate [flags]")
+		fmt.Fprintln(e.stderr, "Measures Argon2id over a grid of memory, iterations and parallelism values and recommends")
+		fmt.Fprintln(e.stderr, "the strongest configuration meeting the latency target at the given concurrency.")
+		fmt.Fprintln(e.stderr, "With -format go, json or env the table is printed to stderr and only the recommendation to stdout.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() != 0 || *target <= 0 || *concurrency < 1 || *runs < 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if !slices.Contains([]string{"text", "go", "json", "env"}, *format) {
+		return errInvalidFormat
+	}
+
+	memoryGrid, err := parseGrid(*memories, maxUint32)
+	if err != nil {
+		return err
+	}
+	iterationGrid, err := parseGrid(*iterations, maxUint32)
+	if err != nil {
+		return err
+	}
+	parallelismGrid, err := parseGrid(*parallelisms, maxUint8)
+	if err != nil {
+		return err
+	}
+
+	// Only the recommendation goes to stdout for the machine readable formats
+	table := e.stdout
+	if *format != "text" {
+		table = e.stderr
+	}
+
+	fmt.Fprintf(table, rowFormat, "m (KiB)", "t", "p", "p50", "p95", "p99", "peak RSS")
+	var results []calibrationResult
+	for _, m := range memoryGrid {
+		for _, t := range iterationGrid {
+			for _, p := range parallelismGrid {
+				r := measureGridPoint(uint32(m), uint32(t), uint8(p), *concurrency, *runs, *target) //nolint:gosec // G115, bounded by parseGrid
+				results = append(results, r)
+				writeResult(table, r) // rows are printed as they complete to show progress
+			}
+		}
+	}
+
+	best, ok := recommend(results, *target, *concurrency, *memoryBudget)
+	if !ok {
+		return errNoCandidate
+	}
+	// The JSON and env output can be loaded with json.Unmarshal and ConfigFromEnv
+	rec := argon2password.Config{
+		Memory:            argon2password.MemorySize(best.memory),
+		Iterations:        best.iterations,
+		Parallelism:       best.parallelism,
+		SaltLength:        argon2password.ArgonSaltLength,
+		KeyLength:         argon2password.ArgonKeyLength,
+		MaxMemory:         max(argon2password.MemorySize(best.memory), argon2password.ArgonMaxMemory),
+		MaxIterations:     max(best.iterations, argon2password.ArgonMaxIterations),
+		MaxPasswordLength: argon2password.ArgonMaxPasswordLength,
+	}
+
+	switch *format {
+	case "go":
+		writeGoConfig(e.stdout, rec)
+	case "json":
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rec); err != nil {
+			return err //nolint:wrapcheck // encoding to stdout
+		}
+	case "env":
+		writeEnvConfig(e.stdout, rec, *envPrefix)
+	default:
+		fmt.Fprintf(e.stdout, "\nRecommended for a p95 of %s at concurrency %d: m=%d,t=%d,p=%d (p95 %s)\n",
+			*target, *concurrency, rec.Memory.KiB(), rec.Iterations, rec.Parallelism, best.p95.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// parseGrid parses a comma separated list of positive integers up to maxValue
+func parseGrid(s string, maxValue uint64) ([]uint64, error) {
+	fields := strings.Split(s, ",")
+	values := make([]uint64, 0, len(fields))
+	for _, field := range fields {
+		v, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil || v == 0 || v > maxValue {
+			return nil, fmt.Errorf("%w: %q", errInvalidGrid, field)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// measureGridPoint hashes runs times on each of concurrency goroutines and
+// returns the latency percentiles over all samples
+func measureGridPoint(memory, iterations uint32, parallelism uint8, concurrency, runs int, target time.Duration) calibrationResult {
+	result := calibrationResult{memory: memory, iterations: iterations, parallelism: parallelism}
+
+	// Start from a clean heap so the peak reflects this grid point only
+	runtime.GC()
+	debug.FreeOSMemory()
+	resetPeakRSS()
+
+	// A single hash far over the target won't become a candidate, don't spend more time on it
+	if first := hashOnce(memory, iterations, parallelism); first > 2*target {
+		result.p50, result.p95, result.p99 = first, first, first
+		result.skipped = true
+		result.peakRSS, _ = peakRSS()
+		return result
+	}
+
+	samples := make([]time.Duration, 0, concurrency*runs)
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := make([]time.Duration, 0, runs)
+			for range runs {
+				local = append(local, hashOnce(memory, iterations, parallelism))
+			}
+			mu.Lock()
+			samples = append(samples, local...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(samples)
+	result.p50 = percentile(samples, 50)
+	result.p95 = percentile(samples, 95)
+	result.p99 = percentile(samples, 99)
+	result.peakRSS, _ = peakRSS()
+	return result
+}
+
+func hashOnce(memory, iterations uint32, parallelism uint8) time.Duration {
+	password := []byte("argon2password-calibration")
+	salt := make([]byte, argon2password.ArgonSaltLength)
+	start := time.Now()
+	argon2.IDKey(password, salt, iterations, memory, parallelism, argon2password.ArgonKeyLength)
+	return time.Since(start)
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []time.Duration, pct int) time.Duration {
+	rank := (pct*len(sorted) + 99) / 100 //nolint:mnd // ceil(pct/100 * n)
+	return sorted[max(rank, 1)-1]
+}
+
+// recommend picks the strongest measured configuration within the target.
+// Following RFC 9106 memory is preferred over iterations, ties go to the lowest latency.
+func recommend(results []calibrationResult, target time.Duration, concurrency int, budget uint) (calibrationResult, bool) {
+	var (
+		best  calibrationResult
+		found bool
+	)
+	for _, r := range results {
+		switch {
+		case r.skipped, r.p95 > target:
+			continue
+		case budget != 0 && uint64(r.memory)*uint64(concurrency) > uint64(budget):
+			continue
+		case !argon2password.MeetsOWASPMinimum(argon2password.MemorySize(r.memory), r.iterations):
+			continue
+		}
+		if !found || stronger(r, best) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+func stronger(a, b calibrationResult) bool {
+	switch {
+	case a.memory != b.memory:
+		return a.memory > b.memory
+	case a.iterations != b.iterations:
+		return a.iterations > b.iterations
+	default:
+		return a.p95 < b.p95
+	}
+}
+
+func writeResult(w io.Writer, r calibrationResult) {
+	rss := "n/a"
+	if r.peakRSS != 0 {
+		rss = fmt.Sprintf("%.0f MiB", float64(r.peakRSS)/(1<<20))
+	}
+	p50, p95, p99 := r.p50.Round(time.Millisecond).String(), r.p95.Round(time.Millisecond).String(), r.p99.Round(time.Millisecond).String()
+	if r.skipped {
+		p50, p95, p99 = ">"+p50, "-", "-"
+	}
+	fmt.Fprintf(w, rowFormat, strconv.FormatUint(uint64(r.memory), 10), strconv.FormatUint(uint64(r.iterations), 10),
+		strconv.FormatUint(uint64(r.parallelism), 10), p50, p95, p99, rss)
+}
+
+func writeGoConfig(w io.Writer, rec argon2password.Config) {
+	fmt.Fprintln(w, "config := &argon2password.Config{")
+	fmt.Fprintf(w, "\tMemory:            %s,\n", goMemorySize(rec.Memory))
+	fmt.Fprintf(w, "\tIterations:        %d,\n", rec.Iterations)
+	fmt.Fprintf(w, "\tParallelism:       %d,\n", rec.Parallelism)
+	fmt.Fprintf(w, "\tSaltLength:        %d,\n", rec.SaltLength)
+	fmt.Fprintf(w, "\tKeyLength:         %d,\n", rec.KeyLength)
+	fmt.Fprintf(w, "\tMaxMemory:         %s,\n", goMemorySize(rec.MaxMemory))
+	fmt.Fprintf(w, "\tMaxIterations:     %d,\n", rec.MaxIterations)
+	fmt.Fprintf(w, "\tMaxPasswordLength: %d,\n", rec.MaxPasswordLength)
+	fmt.Fprintln(w, "}")
+}
+
+// goMemorySize formats m as a Go expression using the MiB or KiB constant
+func goMemorySize(m argon2password.MemorySize) string {
+	if m%argon2password.MiB == 0 {
+		return fmt.Sprintf("%d * argon2password.MiB", m/argon2password.MiB)
+	}
+	return fmt.Sprintf("%d * argon2password.KiB", m.KiB())
+}
+
+func writeEnvConfig(w io.Writer, rec argon2password.Config, prefix string) {
+	fmt.Fprintf(w, "%sMEMORY=%d\n", prefix, rec.Memory.KiB())
+	fmt.Fprintf(w, "%sITERATIONS=%d\n", prefix, rec.Iterations)
+	fmt.Fprintf(w, "%sPARALLELISM=%d\n", prefix, rec.Parallelism)
+	fmt.Fprintf(w, "%sSALT_LENGTH=%d\n", prefix, rec.SaltLength)
+	fmt.Fprintf(w, "%sKEY_LENGTH=%d\n", prefix, rec.KeyLength)
+	fmt.Fprintf(w, "%sMAX_MEMORY=%d\n", prefix, rec.MaxMemory.KiB())
+	fmt.Fprintf(w, "%sMAX_ITERATIONS=%d\n", prefix, rec.MaxIterations)
+	if rec.MaxPasswordLength != 0 {
+		fmt.Fprintf(w, "%sMAX_PASSWORD_LENGTH=%d\n", prefix, rec.MaxPasswordLength)
+	}
+}
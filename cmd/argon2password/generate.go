@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func runGenerate(e *env, args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	length := fs.Int("length", 0, "password length, 0 picks a random length between 32 and 40")
+	charset := fs.String("charset", "", "characters to pick from, defaults to letters, digits and symbols")
+	withHash := fs.Bool("hash", false, "also print the argon2id hash of the password")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password generate [-length N] [-charset CHARS] [-hash]")
+		fmt.Fprintln(e.stderr, "Prints a cryptographically secure random password, and its hash on the next line with -hash.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var (
+		password string
+		err      error
+	)
+	switch {
+	case *charset != "":
+		if *length == 0 {
+			*length = generateDefaultLength
+		}
+		password, err = argon2password.GeneratePasswordWithCharset(*charset, *length)
+	case *length != 0:
+		password, err = argon2password.GeneratePasswordWithLength(*length)
+	default:
+		password, err = argon2password.GeneratePassword()
+	}
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped by argon2password
+	}
+	fmt.Fprintln(e.stdout, password)
+
+	if *withHash {
+		hash, err := argon2password.HashPW(password)
+		if err != nil {
+			return err //nolint:wrapcheck // already wrapped by argon2password
+		}
+		fmt.Fprintln(e.stdout, hash)
+	}
+	return nil
+}
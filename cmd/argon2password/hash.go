@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func runHash(e *env, args []string) error {
+	fs := flag.NewFlagSet("hash", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	memory := fs.Uint("m", uint(argon2password.ArgonMemory), "memory in KiB")
+	iterations := fs.Uint("t", uint(argon2password.ArgonIterations), "iterations")
+	parallelism := fs.Uint("p", 0, "parallelism, 0 uses the available CPUs (GOMAXPROCS and cgroup quota) capped at 4")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password hash [-m KiB] [-t iterations] [-p parallelism]")
+		fmt.Fprintln(e.stderr, "Reads the password from the terminal or the first line of stdin and prints its hash.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var config *argon2password.Config
+	if flagsSet(fs) {
+		var err error
+		if config, err = configFromFlags(*memory, *iterations, *parallelism); err != nil {
+			return err
+		}
+	}
+	hash, err := hashPassword(e, config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "%s\n", hash)
+	return nil
+}
+
+// flagsSet reports whether any flag was given on the command line
+func flagsSet(fs *flag.FlagSet) bool {
+	set := false
+	fs.Visit(func(*flag.Flag) { set = true })
+	return set
+}
+
+// configFromFlags builds a validated Config from command line values
+func configFromFlags(memory, iterations, parallelism uint) (*argon2password.Config, error) {
+	if memory > maxUint32 || iterations > maxUint32 || parallelism > maxUint8 {
+		return nil, errFlagRange
+	}
+	config, err := argon2password.NewConfig(
+		argon2password.WithMemory(argon2password.MemorySize(memory)),
+		argon2password.WithIterations(uint32(iterations)),
+		argon2password.WithParallelism(uint8(parallelism)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+func runInspect(e *env, args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	asJSON := fs.Bool("json", false, "print the parameters as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password inspect [-json] HASH")
+		fmt.Fprintln(e.stderr, "Prints the decoded parameters of a PHC formatted argon2id hash.")
+		fmt.Fprintln(e.stderr, "Hashes over the verification limits are printed with the limits they exceed.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	info, err := argon2password.ParseHash(strings.TrimSpace(fs.Arg(0)))
+	if err != nil {
+		return err //nolint:wrapcheck // already wrapped by argon2password
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info) //nolint:wrapcheck // encoding to stdout
+	}
+
+	fmt.Fprintf(e.stdout, "algorithm:   %s\n", info.Algorithm)
+	fmt.Fprintf(e.stdout, "version:     %d\n", info.Version)
+	fmt.Fprintf(e.stdout, "memory:      %d KiB (%.1f MiB)\n", info.Memory, float64(info.Memory)/1024)
+	fmt.Fprintf(e.stdout, "iterations:  %d\n", info.Iterations)
+	fmt.Fprintf(e.stdout, "parallelism: %d\n", info.Parallelism)
+	fmt.Fprintf(e.stdout, "salt length: %d bytes\n", info.SaltLength)
+	fmt.Fprintf(e.stdout, "key length:  %d bytes\n", info.KeyLength)
+	if info.Normalization != "" {
+		fmt.Fprintf(e.stdout, "normalized:  %s\n", info.Normalization)
+	}
+	if info.PreHash != "" {
+		fmt.Fprintf(e.stdout, "pre-hash:    %s\n", info.PreHash)
+	}
+	if info.Wrapped != "" {
+		fmt.Fprintf(e.stdout, "wrapped:     %s\n", info.Wrapped)
+	}
+	limits := argon2password.DefaultConfig()
+	if info.ExceedsMaxMemory {
+		fmt.Fprintf(e.stdout, "exceeds:     MaxMemory %s, verification is refused\n", limits.MaxMemory)
+	}
+	if info.ExceedsMaxIterations {
+		fmt.Fprintf(e.stdout, "exceeds:     MaxIterations %d, verification is refused\n", limits.MaxIterations)
+	}
+	if info.ExceedsMaxBcryptCost {
+		fmt.Fprintf(e.stdout, "exceeds:     bcrypt cost %d, verification is refused\n", argon2password.LegacyMaxBcryptCost)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Command argon2password hashes, verifies and inspects Argon2id password hashes
+// and generates random passwords, using the argon2password package.
+//
+// Usage:
+//
+//	argon2password <command> [flags] [args]
+//
+// Commands:
+//
+//	hash      hash a password read from the terminal or stdin
+//	verify    check a password against a hash
+//	inspect   print the parameters of an encoded hash
+//	generate  generate a random password
+//	calibrate benchmark a grid of parameters and recommend a Config
+//	audit     classify a dump of stored hashes
+//	migrate   wrap the legacy hashes of a dump in argon2id
+//	breach    build a breached password index from a Pwned Passwords dump
+//	blocklist build a common password Bloom filter from a word list
+//
+// Passwords are read from the terminal without echo, or from the first line of stdin
+// when it is not a terminal, they are never taken from the command line.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Exit codes
+const (
+	exitOK       = 0
+	exitMismatch = 1 // verify: password does not match
+	exitError    = 2 // usage or runtime error
+)
+
+// Limits for values parsed from flags
+const (
+	maxUint32 = 1<<32 - 1
+	maxUint8  = 1<<8 - 1
+)
+
+// generateDefaultLength is used by generate when only -charset is given
+const generateDefaultLength = 32
+
+var (
+	// errMismatch is returned by commands that completed but failed their check
+	errMismatch  = errors.New("password does not match")
+	errUsage     = errors.New("invalid arguments")
+	errFlagRange = errors.New("flag value out of range")
+)
+
+// env holds the streams the commands read from and write to
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"hash":     {summary: "hash a password read from the terminal or stdin", run: runHash},
+	"verify":   {summary: "check a password against a hash", run: runVerify},
+	"inspect":  {summary: "print the parameters of an encoded hash", run: runInspect},
+	"generate": {summary: "generate a random password", run: runGenerate},
+	"audit":    {summary: "classify a dump of stored hashes", run: runAudit},
+	"migrate":  {summary: "wrap the legacy hashes of a dump in argon2id", run: runMigrate},
+	"breach":   {summary: "build a breached password index from a Pwned Passwords dump", run: runBreach},
+	"blocklist": {
+		summary: "build a common password Bloom filter from a word list",
+		run:     runBlocklist,
+	},
+	"calibrate": {
+		summary: "benchmark a grid of parameters and recommend a Config",
+		run:     runCalibrate,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+func run(args []string, e *env) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" || args[0] == "help" {
+		usage(e.stderr)
+		return exitError
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "argon2password: unknown command %q\n\n", args[0])
+		usage(e.stderr)
+		return exitError
+	}
+
+	err := cmd.run(e, args[1:])
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		// Usage was already printed
+		return exitError
+	case errors.Is(err, errMismatch):
+		fmt.Fprintf(e.stderr, "argon2password %s: %v\n", args[0], err)
+		return exitMismatch
+	default:
+		fmt.Fprintf(e.stderr, "argon2password %s: %v\n", args[0], err)
+		return exitError
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: argon2password <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range sortedKeys(commands) {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'argon2password <command> -h' for the flags of a command.")
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
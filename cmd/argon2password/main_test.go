@@ -0,0 +1,570 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"  //nolint:gosec // G501, legacy hashes under test
+	"crypto/sha1" //nolint:gosec // the Pwned Passwords list is keyed by SHA-1
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+	"gopkg.hlmpn.dev/pkg/argon2password/blocklist"
+	"gopkg.hlmpn.dev/pkg/argon2password/breach"
+)
+
+// lowCost are the cheapest parameters NewConfig accepts, PresetOWASP7M5T
+var lowCost = []string{"-m", "7168", "-t", "5", "-p", "1"}
+
+// runCLI runs the command line with stdin and returns the exit code, stdout and stderr
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr})
+	return code, stdout.String(), stderr.String()
+}
+
+func md5Hex(password string) string {
+	sum := md5.Sum([]byte(password)) //nolint:gosec // G401, legacy hashes under test
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRunUsage(t *testing.T) {
+	code, stdout, stderr := runCLI(t, "")
+	if code != exitError || stdout != "" {
+		t.Errorf("run() = %d, stdout %q, want %d and no output", code, stdout, exitError)
+	}
+	for name := range commands {
+		if !strings.Contains(stderr, "  "+name+" ") {
+			t.Errorf("usage is missing the %s command:\n%s", name, stderr)
+		}
+	}
+
+	code, _, stderr = runCLI(t, "", "nope")
+	if code != exitError || !strings.Contains(stderr, `unknown command "nope"`) {
+		t.Errorf("run(nope) = %d, stderr %q, want %d and unknown command", code, stderr, exitError)
+	}
+	for name := range commands {
+		if code, _, stderr := runCLI(t, "", name, "-h"); code != exitError || !strings.Contains(stderr, "Usage: argon2password "+name) {
+			t.Errorf("run(%s -h) = %d, stderr %q, want %d and its usage", name, code, stderr, exitError)
+		}
+	}
+}
+
+func TestHashAndVerify(t *testing.T) {
+	code, hash, stderr := runCLI(t, "correct horse\n", append([]string{"hash"}, lowCost...)...)
+	if code != exitOK || !strings.HasPrefix(hash, "$argon2id$v=19$m=7168,t=5,p=1$") || stderr != "" {
+		t.Fatalf("hash = %d, %q, stderr %q, want %d and a hash", code, hash, stderr, exitOK)
+	}
+	hash = strings.TrimSpace(hash)
+
+	tests := []struct {
+		name       string
+		stdin      string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "Match", stdin: "correct horse\n", args: []string{hash}, wantCode: exitOK, wantStdout: "OK\n"},
+		{name: "Quiet", stdin: "correct horse\n", args: []string{"-q", hash}, wantCode: exitOK},
+		{name: "Mismatch", stdin: "correct horse!\n", args: []string{hash}, wantCode: exitMismatch, wantStderr: "argon2password verify: password does not match\n"},
+		{name: "Invalid hash", stdin: "correct horse\n", args: []string{"$argon2id$nope"}, wantCode: exitError, wantStderr: "argon2password verify: "},
+		{name: "Empty password", stdin: "\n", args: []string{hash}, wantCode: exitError, wantStderr: "argon2password verify: "},
+		{name: "No hash", stdin: "correct horse\n", wantCode: exitError, wantStderr: "Usage: argon2password verify"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tt.stdin, append([]string{"verify"}, tt.args...)...)
+			if code != tt.wantCode || stdout != tt.wantStdout || !strings.HasPrefix(stderr, tt.wantStderr) {
+				t.Errorf("verify = %d, %q, stderr %q, want %d, %q, stderr %q", code, stdout, stderr, tt.wantCode, tt.wantStdout, tt.wantStderr)
+			}
+		})
+	}
+
+	if code, _, stderr := runCLI(t, "x\n", "hash", "-m", "64"); code != exitError || !strings.Contains(stderr, "Memory") {
+		t.Errorf("hash -m 64 = %d, stderr %q, want %d and a Memory error", code, stderr, exitError)
+	}
+	if code, _, _ := runCLI(t, "", append([]string{"hash"}, lowCost...)...); code != exitError {
+		t.Errorf("hash of an empty input = %d, want %d", code, exitError)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	const salt, key = "c29tZXNhbHRzb21lc2FsdA", "c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g"
+	hash := "$argon2id$v=19$m=65536,t=3,p=4,n=nfc$" + salt + "$" + key
+
+	code, stdout, _ := runCLI(t, "", "inspect", hash)
+	want := `algorithm:   argon2id
+version:     19
+memory:      65536 KiB (64.0 MiB)
+iterations:  3
+parallelism: 4
+salt length: 16 bytes
+key length:  32 bytes
+normalized:  nfc
+`
+	if code != exitOK || stdout != want {
+		t.Errorf("inspect = %d,\n%s\nwant %d,\n%s", code, stdout, exitOK, want)
+	}
+
+	code, stdout, _ = runCLI(t, "", "inspect", "-json", hash)
+	var info argon2password.HashInfo
+	if err := json.Unmarshal([]byte(stdout), &info); code != exitOK || err != nil {
+		t.Fatalf("inspect -json = %d, %v", code, err)
+	}
+	if info.Memory != 65536 || info.Normalization != "nfc" || info.SaltLength != 16 {
+		t.Errorf("inspect -json = %+v", info)
+	}
+
+	// A PresetRFC9106First hash is over the default MaxMemory, it is printed with the exceeded limit
+	code, stdout, _ = runCLI(t, "", "inspect", "$argon2id$v=19$m=2097152,t=1,p=4$"+salt+"$"+key)
+	if code != exitOK || !strings.Contains(stdout, "memory:      2097152 KiB (2048.0 MiB)\n") ||
+		!strings.HasSuffix(stdout, "exceeds:     MaxMemory 512 MiB, verification is refused\n") {
+		t.Errorf("inspect over the limits = %d,\n%s", code, stdout)
+	}
+	code, stdout, _ = runCLI(t, "", "inspect", "$argon2id$v=19$m=65536,t=20,p=4$"+salt+"$"+key)
+	if code != exitOK || !strings.HasSuffix(stdout, "exceeds:     MaxIterations 10, verification is refused\n") {
+		t.Errorf("inspect over MaxIterations = %d,\n%s", code, stdout)
+	}
+
+	if code, stdout, stderr := runCLI(t, "", "inspect", "not-a-hash"); code != exitError || stdout != "" || !strings.HasPrefix(stderr, "argon2password inspect: ") {
+		t.Errorf("inspect of an invalid hash = %d, %q, stderr %q", code, stdout, stderr)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	code, stdout, _ := runCLI(t, "", "generate", "-length", "24")
+	if code != exitOK || len(stdout) != 25 || !strings.HasSuffix(stdout, "\n") {
+		t.Errorf("generate -length 24 = %d, %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI(t, "", "generate", "-charset", "ab", "-length", "10")
+	if code != exitOK || strings.Trim(stdout, "ab") != "\n" || len(stdout) != 11 {
+		t.Errorf("generate -charset ab = %d, %q", code, stdout)
+	}
+
+	code, stdout, _ = runCLI(t, "", "generate", "-hash")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if code != exitOK || len(lines) != 2 {
+		t.Fatalf("generate -hash = %d, %q, want a password and its hash", code, stdout)
+	}
+	if match, err := argon2password.ComparePW(lines[0], lines[1]); err != nil || !match {
+		t.Errorf("ComparePW() of the generated password = %v, %v, want true, nil", match, err)
+	}
+
+	if code, _, _ := runCLI(t, "", "generate", "-length", "-1"); code != exitError {
+		t.Errorf("generate -length -1 = %d, want %d", code, exitError)
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	grid := []string{"calibrate", "-m", "7168", "-t", "5", "-p", "1", "-runs", "1", "-target", "10s"}
+
+	code, stdout, stderr := runCLI(t, "", append(grid, "-format", "env", "-env-prefix", "APP_")...)
+	want := `APP_MEMORY=7168
+APP_ITERATIONS=5
+APP_PARALLELISM=1
+APP_SALT_LENGTH=16
+APP_KEY_LENGTH=32
+APP_MAX_MEMORY=524288
+APP_MAX_ITERATIONS=10
+APP_MAX_PASSWORD_LENGTH=4096
+`
+	if code != exitOK || stdout != want {
+		t.Errorf("calibrate -format env = %d,\n%s\nwant\n%s", code, stdout, want)
+	}
+	// The table goes to stderr with the machine readable formats
+	if !strings.Contains(stderr, "m (KiB)") || !strings.Contains(stderr, "7168") {
+		t.Errorf("calibrate -format env stderr = %q, want the measurement table", stderr)
+	}
+
+	code, stdout, _ = runCLI(t, "", append(grid, "-format", "json")...)
+	var config argon2password.Config
+	if err := json.Unmarshal([]byte(stdout), &config); code != exitOK || err != nil {
+		t.Fatalf("calibrate -format json = %d, %v", code, err)
+	}
+	if config.Memory != 7*argon2password.MiB || config.MaxPasswordLength != argon2password.ArgonMaxPasswordLength {
+		t.Errorf("calibrate -format json = %+v", config)
+	}
+
+	code, stdout, _ = runCLI(t, "", grid...)
+	if code != exitOK || !strings.Contains(stdout, "Recommended for a p95 of 10s at concurrency 1: m=7168,t=5,p=1") {
+		t.Errorf("calibrate = %d,\n%s", code, stdout)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{name: "Unknown format", args: []string{"calibrate", "-format", "yaml"}, wantStderr: errInvalidFormat.Error()},
+		{name: "Invalid grid", args: []string{"calibrate", "-m", "7168,0"}, wantStderr: errInvalidGrid.Error()},
+		{name: "No candidate", args: []string{"calibrate", "-m", "7168", "-t", "5", "-p", "1", "-runs", "1", "-target", "1ns"}, wantStderr: errNoCandidate.Error()},
+		{name: "Extra argument", args: []string{"calibrate", "extra"}, wantStderr: "Usage: argon2password calibrate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, stderr := runCLI(t, "", tt.args...); code != exitError || !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("%v = %d, stderr %q, want %d and %q", tt.args, code, stderr, exitError, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestParseGrid(t *testing.T) {
+	tests := []struct {
+		input   string
+		max     uint64
+		want    []uint64
+		wantErr bool
+	}{
+		{input: "19456,47104", max: maxUint32, want: []uint64{19456, 47104}},
+		{input: " 1, 2 ,3", max: maxUint8, want: []uint64{1, 2, 3}},
+		{input: "255", max: maxUint8, want: []uint64{255}},
+		{input: "256", max: maxUint8, wantErr: true},
+		{input: "0", max: maxUint32, wantErr: true},
+		{input: "1,,2", max: maxUint32, wantErr: true},
+		{input: "-1", max: maxUint32, wantErr: true},
+		{input: "64MiB", max: maxUint32, wantErr: true},
+		{input: "", max: maxUint32, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseGrid(tt.input, tt.max)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGrid(%q) = %v, %v, want %v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	point := func(memory, iterations uint32, p95 time.Duration) calibrationResult {
+		return calibrationResult{memory: memory, iterations: iterations, parallelism: 1, p95: p95}
+	}
+	results := []calibrationResult{
+		point(19456, 2, 80*time.Millisecond),
+		point(19456, 3, 110*time.Millisecond),
+		point(47104, 1, 90*time.Millisecond),
+		point(47104, 2, 180*time.Millisecond),
+		point(65536, 1, 300*time.Millisecond),
+		point(4096, 10, 50*time.Millisecond), // below the OWASP minimum
+		{memory: 131072, iterations: 1, parallelism: 1, p95: time.Second, skipped: true},
+	}
+
+	tests := []struct {
+		name        string
+		target      time.Duration
+		concurrency int
+		budget      uint
+		want        calibrationResult
+		wantOK      bool
+	}{
+		{name: "Memory before iterations", target: 200 * time.Millisecond, concurrency: 1, want: results[3], wantOK: true},
+		{name: "Tighter target", target: 100 * time.Millisecond, concurrency: 1, want: results[2], wantOK: true},
+		{name: "Memory budget", target: 200 * time.Millisecond, concurrency: 4, budget: 4 * 20000, want: results[1], wantOK: true},
+		{name: "Skipped points are never picked", target: 2 * time.Second, concurrency: 1, want: results[4], wantOK: true},
+		{name: "Nothing fits", target: 10 * time.Millisecond, concurrency: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := recommend(results, tt.target, tt.concurrency, tt.budget)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("recommend() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	// Ties go to the lowest latency
+	tied := []calibrationResult{point(19456, 2, 90*time.Millisecond), point(19456, 2, 70*time.Millisecond)}
+	if got, _ := recommend(tied, time.Second, 1, 0); got != tied[1] {
+		t.Errorf("recommend() of a tie = %+v, want %+v", got, tied[1])
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	rec := argon2password.Config{
+		Memory:            19 * argon2password.MiB,
+		Iterations:        2,
+		Parallelism:       1,
+		SaltLength:        16,
+		KeyLength:         32,
+		MaxMemory:         512 * argon2password.MiB,
+		MaxIterations:     10,
+		MaxPasswordLength: 4096,
+	}
+
+	var buf bytes.Buffer
+	writeGoConfig(&buf, rec)
+	want := `config := &argon2password.Config{
+	Memory:            19 * argon2password.MiB,
+	Iterations:        2,
+	Parallelism:       1,
+	SaltLength:        16,
+	KeyLength:         32,
+	MaxMemory:         512 * argon2password.MiB,
+	MaxIterations:     10,
+	MaxPasswordLength: 4096,
+}
+`
+	if buf.String() != want {
+		t.Errorf("writeGoConfig() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	rec.Memory = 1536 * argon2password.KiB
+	writeGoConfig(&buf, rec)
+	if !strings.Contains(buf.String(), "\tMemory:            1536 * argon2password.KiB,\n") {
+		t.Errorf("writeGoConfig() with KiB memory =\n%s", buf.String())
+	}
+
+	buf.Reset()
+	writeEnvConfig(&buf, rec, "ARGON2_")
+	want = `ARGON2_MEMORY=1536
+ARGON2_ITERATIONS=2
+ARGON2_PARALLELISM=1
+ARGON2_SALT_LENGTH=16
+ARGON2_KEY_LENGTH=32
+ARGON2_MAX_MEMORY=524288
+ARGON2_MAX_ITERATIONS=10
+ARGON2_MAX_PASSWORD_LENGTH=4096
+`
+	if buf.String() != want {
+		t.Errorf("writeEnvConfig() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// An unset MaxPasswordLength is left out rather than printed as 0, which ConfigFromEnv would reject
+	buf.Reset()
+	rec.MaxPasswordLength = 0
+	writeEnvConfig(&buf, rec, "")
+	if strings.Contains(buf.String(), "MAX_PASSWORD_LENGTH") {
+		t.Errorf("writeEnvConfig() with no MaxPasswordLength =\n%s", buf.String())
+	}
+}
+
+func TestAudit(t *testing.T) {
+	current, err := argon2password.HashWithConfig("password", argon2password.PresetOWASP7M5T.Config())
+	if err != nil {
+		t.Fatalf("HashWithConfig() error = %v", err)
+	}
+	input := strings.Join([]string{
+		"hash",
+		current,
+		"$argon2id$v=19$m=4096,t=5,p=1$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g",
+		"$argon2id$v=19$m=2097152,t=1,p=4$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g",
+		md5Hex("password"),
+		"not a hash",
+		"",
+	}, "\n")
+
+	code, stdout, _ := runCLI(t, input, "audit", "-header", "-json", "-m", "7168", "-t", "5")
+	var report argon2password.AuditReport
+	if err := json.Unmarshal([]byte(stdout), &report); code != exitOK || err != nil {
+		t.Fatalf("audit -json = %d, %v\n%s", code, err, stdout)
+	}
+	wantCounts := map[argon2password.AuditClass]int{
+		argon2password.AuditCurrent:   1,
+		argon2password.AuditWeak:      1,
+		argon2password.AuditWrapped:   0,
+		argon2password.AuditLegacy:    1,
+		argon2password.AuditMalformed: 1,
+		argon2password.AuditDoSRisk:   1,
+	}
+	if report.Total != 5 || !reflect.DeepEqual(report.Counts, wantCounts) || len(report.Results) != 5 {
+		t.Errorf("audit -json = %+v, want %v", report, wantCounts)
+	}
+
+	code, stdout, _ = runCLI(t, input, "audit", "-header", "-m", "7168", "-t", "5")
+	if code != exitOK || !strings.HasPrefix(stdout, "5 hashes\n") ||
+		!strings.Contains(stdout, "argon2id-current          1  20.0% ########################################\n") ||
+		!strings.Contains(stdout, "  md5                           1\n") {
+		t.Errorf("audit = %d,\n%s", code, stdout)
+	}
+
+	// CSV with an id column, from a file
+	path := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(path, []byte("id,hash\nalice,"+md5Hex("a")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, _ = runCLI(t, "", "audit", "-csv-column", "2", "-id-column", "1", "-header", "-json", path)
+	if code != exitOK || !strings.Contains(stdout, `"id": "alice"`) || !strings.Contains(stdout, `"class": "legacy"`) {
+		t.Errorf("audit -csv-column = %d,\n%s", code, stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "", "audit", filepath.Join(t.TempDir(), "missing")); code != exitError || !strings.Contains(stderr, "missing") {
+		t.Errorf("audit of a missing file = %d, stderr %q", code, stderr)
+	}
+}
+
+func TestMigrateLines(t *testing.T) {
+	// More entries than one batch of -workers 1, so the batches must be written in order
+	const entries = migrateBatchPerWorker + 4
+	lines := []string{"hash"}
+	for i := range entries {
+		switch i % 4 {
+		case 1:
+			lines = append(lines, "") // empty lines are kept
+		case 2:
+			lines = append(lines, "$argon2id$v=19$m=7168,t=5,p=1$c29tZXNhbHRzb21lc2FsdA$c29tZWhhc2hzb21laGFzaHNvbWVoYXNoc29tZWhhc2g")
+		case 3:
+			lines = append(lines, fmt.Sprintf("unknown-%d", i))
+		default:
+			lines = append(lines, md5Hex(fmt.Sprintf("password-%d", i)))
+		}
+	}
+
+	args := append([]string{"migrate", "-header", "-keep-unknown", "-workers", "1"}, lowCost...)
+	code, stdout, stderr := runCLI(t, strings.Join(lines, "\n")+"\n", args...)
+	if code != exitOK {
+		t.Fatalf("migrate = %d, stderr %q", code, stderr)
+	}
+	if stderr != "wrapped 5, unchanged 10, kept unknown 5\n" {
+		t.Errorf("migrate stats = %q", stderr)
+	}
+	out := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(out) != len(lines) || out[0] != "hash" {
+		t.Fatalf("migrate wrote %d lines, want %d with the header first:\n%s", len(out), len(lines), stdout)
+	}
+	for i, line := range out[1:] {
+		if i%4 != 0 {
+			if line != lines[i+1] {
+				t.Errorf("line %d = %q, want it unchanged %q", i+2, line, lines[i+1])
+			}
+			continue
+		}
+		if match, err := argon2password.ComparePW(fmt.Sprintf("password-%d", i), line); err != nil || !match {
+			t.Errorf("line %d = %q, ComparePW(password-%d) = %v, %v, want the wrapped hash in place", i+2, line, i, match, err)
+		}
+	}
+}
+
+func TestMigrateCSV(t *testing.T) {
+	input := "id;hash;name\n" +
+		"1;" + md5Hex("one") + ";alice\n" +
+		"2;bogus;bob\n" +
+		"3;" + md5Hex("three") + ";carol\n"
+
+	args := append([]string{"migrate", "-csv-column", "2", "-comma", ";", "-header", "-keep-unknown"}, lowCost...)
+	code, stdout, stderr := runCLI(t, input, args...)
+	if code != exitOK || stderr != "wrapped 2, unchanged 0, kept unknown 1\n" {
+		t.Fatalf("migrate -csv-column = %d, stderr %q", code, stderr)
+	}
+	reader := csv.NewReader(strings.NewReader(stdout))
+	reader.Comma = ';'
+	records, err := reader.ReadAll()
+	if err != nil || len(records) != 4 {
+		t.Fatalf("migrate -csv-column output = %q, %v", stdout, err)
+	}
+	if !reflect.DeepEqual(records[0], []string{"id", "hash", "name"}) || !reflect.DeepEqual(records[2], []string{"2", "bogus", "bob"}) {
+		t.Errorf("migrate -csv-column changed the header or the unknown record: %q", records)
+	}
+	for _, i := range []int{1, 3} {
+		password := map[int]string{1: "one", 3: "three"}[i]
+		if match, err := argon2password.ComparePW(password, records[i][1]); err != nil || !match || records[i][0] != fmt.Sprint(i) {
+			t.Errorf("record %d = %q, ComparePW() = %v, %v", i+1, records[i], match, err)
+		}
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	// The lines before the failing one are written, matching the stats
+	input := md5Hex("one") + "\nbogus\n" + md5Hex("three") + "\n"
+	code, stdout, stderr := runCLI(t, input, append([]string{"migrate"}, lowCost...)...)
+	if code != exitError || !strings.Contains(stderr, "wrapped 1,") || !strings.Contains(stderr, "migrate: line 2: ") {
+		t.Errorf("migrate of an unknown hash = %d, stderr %q", code, stderr)
+	}
+	if lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n"); len(lines) != 1 || !argon2password.IsWrappedHash(lines[0]) {
+		t.Errorf("migrate of an unknown hash wrote %q, want the first line wrapped", stdout)
+	}
+
+	// CSV errors are numbered by record, a quoted field may span lines
+	input = "id,hash\n\"1\n\"," + md5Hex("one") + "\n2,bogus\n"
+	code, stdout, stderr = runCLI(t, input, append([]string{"migrate", "-csv-column", "2", "-header"}, lowCost...)...)
+	if code != exitError || !strings.Contains(stderr, "migrate: record 3: ") {
+		t.Errorf("migrate -csv-column of an unknown hash = %d, stderr %q", code, stderr)
+	}
+	if records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll(); err != nil || len(records) != 2 {
+		t.Errorf("migrate -csv-column of an unknown hash wrote %q, want the header and the first record", stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "a,b\n", "migrate", "-csv-column", "3"); code != exitError || !strings.Contains(stderr, errMigrateColumn.Error()) {
+		t.Errorf("migrate -csv-column out of range = %d, stderr %q", code, stderr)
+	}
+	if code, _, _ := runCLI(t, "", "migrate", "-workers", "0"); code != exitError {
+		t.Errorf("migrate -workers 0 = %d, want %d", code, exitError)
+	}
+}
+
+func TestBreach(t *testing.T) {
+	var lines []string
+	for i, password := range []string{"hunter2", "tulipsandmoss", "correct horse"} {
+		sum := sha1.Sum([]byte(password)) //nolint:gosec // test data
+		lines = append(lines, fmt.Sprintf("%X:%d", sum[:], i+1))
+	}
+	sort.Strings(lines)
+	dump := strings.Join(lines, "\r\n") + "\r\n"
+
+	path := filepath.Join(t.TempDir(), "pwned.idx")
+	code, stdout, stderr := runCLI(t, dump, "breach", "-o", path, "-min-count", "2")
+	if code != exitOK || stdout != "" || stderr != "indexed 2 passwords\n" {
+		t.Fatalf("breach -o = %d, %q, stderr %q", code, stdout, stderr)
+	}
+	list, err := breach.Open(path)
+	if err != nil {
+		t.Fatalf("breach.Open() error = %v", err)
+	}
+	defer list.Close()
+	if count, found, err := list.LookupPassword("correct horse"); err != nil || !found || count != 3 {
+		t.Errorf("LookupPassword() = %d, %v, %v, want 3, true, nil", count, found, err)
+	}
+	if _, found, _ := list.LookupPassword("hunter2"); found {
+		t.Error("LookupPassword() found a password under -min-count")
+	}
+
+	code, stdout, _ = runCLI(t, dump, "breach")
+	if code != exitOK || !strings.HasPrefix(stdout, "A2PWBRI1") {
+		t.Errorf("breach to stdout = %d, %q", code, stdout)
+	}
+
+	if code, _, stderr := runCLI(t, lines[2]+"\n"+lines[0]+"\n", "breach"); code != exitError || !strings.Contains(stderr, breach.ErrUnsorted.Error()) {
+		t.Errorf("breach of an unsorted dump = %d, stderr %q", code, stderr)
+	}
+	if code, _, _ := runCLI(t, "", "breach", "-min-count", "4294967296"); code != exitError {
+		t.Errorf("breach -min-count out of range = %d, want %d", code, exitError)
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	words := "hunter2hunter2\ncorrecthorse\n\n"
+	path := filepath.Join(t.TempDir(), "words.bf")
+	code, stdout, stderr := runCLI(t, words, "blocklist", "-o", path, "-fp", "0.001")
+	if code != exitOK || stdout != "" || !strings.HasPrefix(stderr, "2 words, ") {
+		t.Fatalf("blocklist -o = %d, %q, stderr %q", code, stdout, stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := blocklist.Load(data)
+	if err != nil {
+		t.Fatalf("blocklist.Load() error = %v", err)
+	}
+	if !filter.Contains("Hunter2Hunter2") || filter.Len() != 2 {
+		t.Errorf("filter Contains() = %v, Len() = %d, want true, 2", filter.Contains("Hunter2Hunter2"), filter.Len())
+	}
+
+	code, stdout, _ = runCLI(t, words, "blocklist")
+	if code != exitOK || !strings.HasPrefix(stdout, "A2PWBLM1") {
+		t.Errorf("blocklist to stdout = %d, %q", code, stdout)
+	}
+	if code, _, stderr := runCLI(t, words, "blocklist", "-fp", "2"); code != exitError || !strings.Contains(stderr, blocklist.ErrInvalidParams.Error()) {
+		t.Errorf("blocklist -fp 2 = %d, stderr %q", code, stderr)
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// migrateBatchPerWorker is how many entries each worker gets per batch,
+// output order is kept by writing batches in order
+const migrateBatchPerWorker = 16
+
+var errMigrateColumn = errors.New("-csv-column out of range")
+
+// migrateEntry is one line or CSV record of the dump
+type migrateEntry struct {
+	number int      // 1-based line or record number
+	line   string   // line mode
+	record []string // CSV mode
+	header bool     // copied as is
+}
+
+// migrateStats counts what happened to the entries
+type migrateStats struct {
+	wrapped   int
+	unchanged int // argon2id, already wrapped or empty
+	skipped   int // not wrappable, kept as is with -keep-unknown
+}
+
+// migrator wraps the hashes of a dump
+type migrator struct {
+	scheme      argon2password.LegacyScheme
+	config      *argon2password.Config // nil for the defaults
+	column      int                    // 1-based CSV column, 0 in line mode
+	keepUnknown bool
+	workers     int
+}
+
+func runMigrate(e *env, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	csvColumn := fs.Int("csv-column", 0, "1-based CSV column holding the hash, 0 reads one hash per line")
+	comma := fs.String("comma", ",", "CSV field separator")
+	header := fs.Bool("header", false, "copy the first line or record unchanged")
+	scheme := fs.String("scheme", "", "legacy scheme of the hashes: bcrypt, md5, sha1, sha256 or sha512, detected if empty")
+	keepUnknown := fs.Bool("keep-unknown", false, "keep hashes that can't be wrapped unchanged instead of failing")
+	workers := fs.Int("workers", runtime.GOMAXPROCS(0), "number of hashes computed at the same time")
+	memory := fs.Uint("m", uint(argon2password.ArgonMemory), "memory in KiB")
+	iterations := fs.Uint("t", uint(argon2password.ArgonIterations), "iterations")
+	parallelism := fs.Uint("p", 0, "parallelism, 0 uses the available CPUs (GOMAXPROCS and cgroup quota) capped at 4")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password migrate [flags] [FILE]")
+		fmt.Fprintln(e.stderr, "Wraps the legacy hashes read from FILE or stdin in argon2id and writes the dump to stdout.")
+		fmt.Fprintln(e.stderr, "argon2id and already wrapped hashes are copied unchanged. Each wrapped hash uses")
+		fmt.Fprintln(e.stderr, "the memory given with -m, keep -workers low enough for the memory available.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() > 1 || len([]rune(*comma)) != 1 || *csvColumn < 0 || *workers < 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	m := &migrator{
+		scheme:      argon2password.LegacyScheme(*scheme),
+		column:      *csvColumn,
+		keepUnknown: *keepUnknown,
+		workers:     *workers,
+	}
+	if paramFlagsSet(fs) {
+		config, err := configFromFlags(*memory, *iterations, *parallelism)
+		if err != nil {
+			return err
+		}
+		m.config = config
+	}
+
+	input, closeInput, err := openInput(e, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	var stats migrateStats
+	if m.column == 0 {
+		stats, err = m.migrateLines(input, e.stdout, *header)
+	} else {
+		stats, err = m.migrateCSV(input, e.stdout, []rune(*comma)[0], *header)
+	}
+	fmt.Fprintf(e.stderr, "wrapped %d, unchanged %d, kept unknown %d\n", stats.wrapped, stats.unchanged, stats.skipped)
+	return err
+}
+
+// paramFlagsSet reports whether any of the Argon2id parameter flags was given
+func paramFlagsSet(fs *flag.FlagSet) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		set = set || f.Name == "m" || f.Name == "t" || f.Name == "p"
+	})
+	return set
+}
+
+func (m *migrator) migrateLines(r io.Reader, w io.Writer, header bool) (migrateStats, error) {
+	scanner := bufio.NewScanner(r)
+	out := bufio.NewWriter(w)
+	defer out.Flush() // on errors, the lines written so far match the stats
+	var stats migrateStats
+
+	write := func(batch []migrateEntry) error {
+		for _, entry := range batch {
+			if _, err := fmt.Fprintln(out, entry.line); err != nil {
+				return err //nolint:wrapcheck // writing to stdout
+			}
+		}
+		return nil
+	}
+	process := func(batch []migrateEntry) error {
+		done, err := m.processBatch(batch, &stats)
+		if werr := write(batch[:done]); werr != nil {
+			return werr
+		}
+		return err
+	}
+
+	var batch []migrateEntry
+	number := 0
+	for scanner.Scan() {
+		number++
+		batch = append(batch, migrateEntry{number: number, line: scanner.Text(), header: number == 1 && header})
+		if len(batch) == m.workers*migrateBatchPerWorker {
+			if err := process(batch); err != nil {
+				return stats, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("failed to read input: %w", err)
+	}
+	if err := process(batch); err != nil {
+		return stats, err
+	}
+	return stats, out.Flush() //nolint:wrapcheck // writing to stdout
+}
+
+func (m *migrator) migrateCSV(r io.Reader, w io.Writer, comma rune, header bool) (migrateStats, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comma = comma
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	defer writer.Flush() // on errors, the records written so far match the stats
+	var stats migrateStats
+
+	write := func(batch []migrateEntry) error {
+		for _, entry := range batch {
+			if err := writer.Write(entry.record); err != nil {
+				return err //nolint:wrapcheck // writing to stdout
+			}
+		}
+		return nil
+	}
+	process := func(batch []migrateEntry) error {
+		done, err := m.processBatch(batch, &stats)
+		if werr := write(batch[:done]); werr != nil {
+			return werr
+		}
+		return err
+	}
+
+	var batch []migrateEntry
+	number := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stats, fmt.Errorf("failed to read CSV input: %w", err)
+		}
+		number++
+		isHeader := number == 1 && header
+		if !isHeader && m.column > len(record) {
+			return stats, fmt.Errorf("%w: record %d has %d fields", errMigrateColumn, number, len(record))
+		}
+		batch = append(batch, migrateEntry{number: number, record: record, header: isHeader})
+		if len(batch) == m.workers*migrateBatchPerWorker {
+			if err := process(batch); err != nil {
+				return stats, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := process(batch); err != nil {
+		return stats, err
+	}
+	writer.Flush()
+	return stats, writer.Error() //nolint:wrapcheck // writing to stdout
+}
+
+// processBatch wraps the hashes of batch in place on m.workers goroutines.
+// It returns the number of entries before the first failing one, which
+// are counted in stats, and the error of that entry.
+func (m *migrator) processBatch(batch []migrateEntry, stats *migrateStats) (int, error) {
+	results := make([]migrateResult, len(batch))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(m.workers, len(batch)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = m.migrateHash(batch[i])
+			}
+		}()
+	}
+	for i := range batch {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, result := range results {
+		if batch[i].header {
+			continue
+		}
+		if result.err != nil {
+			if m.column > 0 {
+				return i, fmt.Errorf("record %d: %w", batch[i].number, result.err)
+			}
+			return i, fmt.Errorf("line %d: %w", batch[i].number, result.err)
+		}
+		switch result.outcome {
+		case migrateWrapped:
+			stats.wrapped++
+			if m.column == 0 {
+				batch[i].line = result.hash
+			} else {
+				batch[i].record[m.column-1] = result.hash
+			}
+		case migrateUnchanged:
+			stats.unchanged++
+		case migrateSkipped:
+			stats.skipped++
+		}
+	}
+	return len(batch), nil
+}
+
+type migrateOutcome int
+
+const (
+	migrateUnchanged migrateOutcome = iota
+	migrateWrapped
+	migrateSkipped
+)
+
+type migrateResult struct {
+	outcome migrateOutcome
+	hash    string
+	err     error
+}
+
+func (m *migrator) migrateHash(entry migrateEntry) migrateResult {
+	if entry.header {
+		return migrateResult{outcome: migrateUnchanged}
+	}
+	hash := entry.line
+	if m.column > 0 {
+		hash = entry.record[m.column-1]
+	}
+	hash = strings.TrimSpace(hash)
+	if hash == "" || argon2password.IsWrappedHash(hash) || strings.HasPrefix(hash, "$argon2id$") {
+		return migrateResult{outcome: migrateUnchanged}
+	}
+
+	var (
+		wrapped string
+		err     error
+	)
+	if m.config == nil {
+		wrapped, err = argon2password.WrapLegacyHash(hash, m.scheme)
+	} else {
+		wrapped, err = argon2password.WrapLegacyHashWithConfig(hash, m.scheme, m.config)
+	}
+	switch {
+	case err == nil:
+		return migrateResult{outcome: migrateWrapped, hash: wrapped}
+	case m.keepUnknown && (errors.Is(err, argon2password.ErrUnsupportedLegacyScheme) || errors.Is(err, argon2password.ErrInvalidLegacyHash)):
+		return migrateResult{outcome: migrateSkipped}
+	default:
+		return migrateResult{err: err}
+	}
+}
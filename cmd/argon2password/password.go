@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+
+	"gopkg.hlmpn.dev/pkg/argon2password"
+)
+
+// maxPasswordInput bounds how much is read from stdin for a password
+const maxPasswordInput = 4096
+
+var errPasswordMismatch = errors.New("passwords do not match")
+
+// isTerminal reports whether r is a terminal, where prompts are shown
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) //nolint:gosec // G115, file descriptors fit in an int
+}
+
+// hashPassword reads a password from the terminal without echo, or from the first
+// line of stdin when it is not a terminal, and hashes it with config, or the defaults if nil.
+// On a terminal the password is asked twice, the confirmation is compared with the
+// hash so the password is only ever held by the argon2password readers.
+func hashPassword(e *env, config *argon2password.Config) (string, error) {
+	terminal := isTerminal(e.stdin)
+	if terminal {
+		fmt.Fprint(e.stderr, "Password: ")
+	}
+	var hash string
+	var err error
+	if config != nil {
+		hash, err = argon2password.HashFromReaderWithConfig(e.stdin, maxPasswordInput, config)
+	} else {
+		hash, err = argon2password.HashFromReader(e.stdin, maxPasswordInput)
+	}
+	if terminal {
+		fmt.Fprintln(e.stderr)
+	}
+	if err != nil || !terminal {
+		return hash, err //nolint:wrapcheck // already wrapped by argon2password
+	}
+
+	fmt.Fprint(e.stderr, "Confirm password: ")
+	match, err := argon2password.CompareFromReader(e.stdin, maxPasswordInput, hash)
+	fmt.Fprintln(e.stderr)
+	switch {
+	case errors.Is(err, argon2password.ErrEmptyPassword):
+		return "", errPasswordMismatch
+	case err != nil:
+		return "", err //nolint:wrapcheck // already wrapped by argon2password
+	case !match:
+		return "", errPasswordMismatch
+	}
+	return hash, nil
+}
+
+// comparePassword reads a password like hashPassword and compares it with hash
+func comparePassword(e *env, hash string) (bool, error) {
+	terminal := isTerminal(e.stdin)
+	if terminal {
+		fmt.Fprint(e.stderr, "Password: ")
+	}
+	match, err := argon2password.CompareFromReader(e.stdin, maxPasswordInput, hash)
+	if terminal {
+		fmt.Fprintln(e.stderr)
+	}
+	return match, err //nolint:wrapcheck // already wrapped by argon2password
+}
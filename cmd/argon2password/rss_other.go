@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+// resetPeakRSS is a no-op where the peak RSS can't be reset
+func resetPeakRSS() {}
+
+// peakRSS is not reported outside Linux
+func peakRSS() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+func runVerify(e *env, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	quiet := fs.Bool("q", false, "don't print the result, only set the exit code")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "Usage: argon2password verify [-q] HASH")
+		fmt.Fprintln(e.stderr, "Reads the password from the terminal or the first line of stdin and checks it against HASH.")
+		fmt.Fprintln(e.stderr, "Exits with 0 if it matches, 1 if it doesn't and 2 on errors.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage // the flag package already printed the error and usage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	hash := strings.TrimSpace(fs.Arg(0))
+
+	match, err := comparePassword(e, hash)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return errMismatch
+	}
+	if !*quiet {
+		fmt.Fprintln(e.stdout, "OK")
+	}
+	return nil
+}
@@ -10,16 +10,29 @@ const (
 	// - m=12288 (12 MiB), t=3, p=1
 	// - m=9216 (9 MiB), t=4, p=1
 	// - m=7168 (7 MiB), t=5, p=1
+	// These are available as presets, see presets.go
 
 	// OWASP recommended parameters for Argon2id (2025)
-	ArgonMemory     uint32 = 64 * 1024 // 64MB
-	ArgonIterations uint32 = 3         // 3 iterations for enhanced security
-	ArgonSaltLength uint32 = 16        // 16 bytes salt (128-bit)
-	ArgonKeyLength  uint32 = 32        // 32 bytes key (256-bit)
+	ArgonMemory     MemorySize = 64 * MiB // 64 MiB
+	ArgonIterations uint32     = 3        // 3 iterations for enhanced security
+	ArgonSaltLength uint32     = 16       // 16 bytes salt (128-bit)
+	ArgonKeyLength  uint32     = 32       // 32 bytes key (256-bit)
 
 	// DoS protection parameters - maximum allowed values for verification
-	ArgonMaxMemory     uint32 = 512 * 1024 // Max 512MB
-	ArgonMaxIterations uint32 = 10         // Max 10 iterations
+	ArgonMaxMemory     MemorySize = 512 * MiB // Max 512 MiB
+	ArgonMaxIterations uint32     = 10        // Max 10 iterations
+
+	// Highest cost of a wrapped bcrypt hash accepted for wrapping and verification,
+	// each step doubles the time spent on the legacy hash before argon2id
+	LegacyMaxBcryptCost = 16
+
+	// Longest password accepted for hashing and verification, in bytes.
+	// Argon2id first hashes the whole password with Blake2b, so without a bound
+	// a 10 MB login request costs as much time and memory as it likes.
+	ArgonMaxPasswordLength uint32 = 4096
+
+	// Smallest memory NewConfig accepts, lower values are taken as a unit mistake
+	argonMinMemory MemorySize = 1 * MiB
 
 	// Optimal parallelism cap to balance security and performance
 	ArgonMaxParallelism uint8 = 4
@@ -27,10 +40,11 @@ const (
 
 // Misc constants
 const (
-	ArgonEncodedPartCount int = 6                  // Number of parts in a valid encoded hash
-	uint32MaxValue        int = 4294967295 - 1     // minus 1 to avoid any mistakes leading to overflow
-	int32MaxValue         int = uint32MaxValue / 2 // Max value for int32
-	uint8MaxValue         int = 255                // Max value for uint8
+	ArgonEncodedPartCount   int = 6                  // Number of parts in a valid encoded hash
+	WrappedEncodedPartCount int = 7                  // Number of parts in a valid encoded wrapped hash
+	uint32MaxValue          int = 4294967295 - 1     // minus 1 to avoid any mistakes leading to overflow
+	int32MaxValue           int = uint32MaxValue / 2 // Max value for int32
+	uint8MaxValue           int = 255                // Max value for uint8
 )
 
 // String constants
@@ -42,6 +56,9 @@ const (
 	commaPEqual               = ",p="
 	dollarSign                = "$"
 	argonAlgoAndVersionPrefix = "$argon2id$v="
+	argon2idPrefix            = "$argon2id$"
+	argon2idWrap              = "argon2id-wrap"
+	argon2idWrapPrefix        = "$argon2id-wrap$"
 	dollarMEqual              = "$m="
 )
 
@@ -55,6 +72,8 @@ var (
 	dollarMEqualsBytes             = []byte(dollarMEqual)
 	dollarSignBytes                = []byte(dollarSign)
 	argonAlgoAndVersionPrefixBytes = []byte(argonAlgoAndVersionPrefix)
+	argon2idWrapBytes              = []byte(argon2idWrap)
+	argon2idWrapPrefixBytes        = []byte(argon2idWrapPrefix)
 )
 
 // byte values for parsing
@@ -2,90 +2,183 @@ package argon2password
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Config provides customizable parameters for Argon2id hashing.
 // All fields are optional - if a field is set to 0 or left uninitialized,
 // the corresponding default value will be used.
-// Negative values are not allowed.
+// Use NewConfig to fill in the defaults and validate a Config.
+// A Config can also be loaded from JSON, YAML or the environment, see ConfigFromEnv.
 type Config struct {
-	// Memory usage in megabytes(MB).
-	// Defaults to 64 MB if unset(0).
-	// OWASP minimum recommendation: 46 MB.
-	Memory uint32
+	// Memory usage in KiB, write it with the units, e.g. 64 * MiB.
+	// Defaults to 64 MiB if unset(0).
+	// OWASP minimum recommendation: 46 MiB with 1 iteration, see the OWASP presets.
+	Memory MemorySize `json:"memory" yaml:"memory"`
 
 	// Number of iterations.
 	// Defaults to 3 if unset(0).
 	// OWASP minimum recommendation: 1-5 (depending on memory).
-	Iterations uint32
+	Iterations uint32 `json:"iterations" yaml:"iterations"`
 
 	// Salt length in bytes.
 	// Defaults to 16 bytes if unset(0).
 	// OWASP minimum recommendation: 16 bytes.
-	SaltLength uint32
+	SaltLength uint32 `json:"salt_length" yaml:"salt_length"`
 
 	// Key length in bytes.
 	// Defaults to 32 bytes if unset(0).
 	// OWASP minimum recommendation: 32 bytes.
-	KeyLength uint32
+	KeyLength uint32 `json:"key_length" yaml:"key_length"`
 
-	// Parallelism factor - threads count. All values gets capped at cpu count.
-	// Defaults to the available number of CPU cores up to a maximum of 4 if unset(0).
+	// Parallelism factor - threads count. Values over ArgonMaxParallelism get capped at cpu count,
+	// so the presets (up to 4 lanes) hash with the same parameters on every machine.
+	// Defaults to the number of CPUs available to the process, the smaller of GOMAXPROCS
+	// and the cgroup CPU quota, up to a maximum of 4 if unset(0).
 	// OWASP examples use 1, but higher is better for multi-core systems.
-	Parallelism uint8
+	Parallelism uint8 `json:"parallelism" yaml:"parallelism"`
 
-	// Max memory in megabytes(MB).
-	// Defaults to 512 if unset(0).
-	MaxMemory uint32
+	// Max memory allowed for verification, in KiB like Memory.
+	// Defaults to 512 MiB if unset(0).
+	MaxMemory MemorySize `json:"max_memory" yaml:"max_memory"`
 
 	// Max iterations allowed for verification.
 	// Defaults to 10 if unset(0).
-	MaxIterations uint32
+	MaxIterations uint32 `json:"max_iterations" yaml:"max_iterations"`
+
+	// Longest password accepted for hashing and verification, in bytes.
+	// Longer passwords are rejected with a *PasswordTooLongError before any work is done.
+	// Defaults to 4096 if unset(0).
+	MaxPasswordLength uint32 `json:"max_password_length" yaml:"max_password_length"`
+
+	// PreHash is applied to passwords before Argon2id and recorded in the hash.
+	// Defaults to none, see PreHashHMACSHA512V1.
+	PreHash PreHash `json:"pre_hash,omitempty" yaml:"pre_hash,omitempty"`
+
+	// Normalization is the Unicode normalization applied to passwords before
+	// the pre-hash and recorded in the hash. Defaults to none, see NormalizationNFC.
+	Normalization Normalization `json:"normalization,omitempty" yaml:"normalization,omitempty"`
 }
 
+// Config validation errors, wrapped in a ConfigError naming the field
 var (
-	ErrConfigNil                  = newConfigError("config is nil")
-	ErrConfigNegativeValue        = newConfigError("value is negative")
-	ErrConfigMemoryExceedsMax     = newConfigError("memory exceeds max memory defined in the config")
-	ErrConfigIterationsExceedsMax = newConfigError("iterations exceeds max iterations defined in the config")
+	ErrConfigNil                  = errors.New("argon2Password: Config is nil")
+	ErrConfigNegativeValue        = errors.New("argon2Password: Config value is negative")
+	ErrConfigMemoryExceedsMax     = errors.New("argon2Password: Memory exceeds max memory defined in the config")
+	ErrConfigIterationsExceedsMax = errors.New("argon2Password: Iterations exceeds max iterations defined in the config")
+	ErrConfigMemoryTooSmall       = errors.New("argon2Password: Memory is below 1 MiB, memory is in KiB, e.g. 64 * MiB")
+	ErrConfigBelowOWASPMinimum    = errors.New("argon2Password: Memory and iterations are below the OWASP minimum, see the OWASP presets")
+	ErrConfigMemoryParallelism    = errors.New("argon2Password: Memory must be at least 8 KiB per lane of parallelism")
+	ErrConfigSaltTooShort         = errors.New("argon2Password: Salt length is below the 16 bytes minimum")
+	ErrConfigKeyTooShort          = errors.New("argon2Password: Key length is below the 32 bytes minimum")
+)
+
+// Minimum salt and key lengths accepted by NewConfig, in bytes
+const (
+	configMinSaltLength uint32 = 16
+	configMinKeyLength  uint32 = 32
 )
 
+// ConfigError is returned by NewConfig for an invalid field.
+// Err is one of the ErrConfig values, so errors.Is can be used to check the reason.
 type ConfigError struct {
-	m string
+	Field string // name of the Config field, e.g. "Memory"
+	Value uint64 // value of the field after defaults were applied
+	Err   error
 }
 
-func newConfigError(msg string) *ConfigError {
-	return &ConfigError{m: msg}
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%v (%s=%d)", e.Err, e.Field, e.Value)
 }
 
-func (e ConfigError) Error() string {
-	return e.m
+func (e *ConfigError) Unwrap() error {
+	return e.Err
 }
-func (e ConfigError) Unwrap() error {
-	return errors.Unwrap(e)
+
+// IsConfigError reports whether err is, or wraps, a ConfigError.
+func IsConfigError(err error) bool {
+	var configErr *ConfigError
+	return errors.As(err, &configErr)
 }
 
-func (e ConfigError) Is(target error) bool {
-	return errors.Is(e, target)
+// Option sets a Config field, see NewConfig.
+type Option func(*Config)
+
+// WithMemory sets the memory, e.g. WithMemory(64 * MiB).
+func WithMemory(memory MemorySize) Option {
+	return func(c *Config) { c.Memory = memory }
 }
 
-func IsConfigError(err error) bool {
-	return false
+// WithIterations sets the number of iterations.
+func WithIterations(iterations uint32) Option {
+	return func(c *Config) { c.Iterations = iterations }
+}
+
+// WithParallelism sets the number of lanes.
+func WithParallelism(parallelism uint8) Option {
+	return func(c *Config) { c.Parallelism = parallelism }
+}
+
+// WithSaltLength sets the salt length in bytes.
+func WithSaltLength(length uint32) Option {
+	return func(c *Config) { c.SaltLength = length }
+}
+
+// WithKeyLength sets the key length in bytes.
+func WithKeyLength(length uint32) Option {
+	return func(c *Config) { c.KeyLength = length }
 }
 
-func NewConfig(maxMemory uint32, maxIterations uint32, memory uint32, iterations uint32, saltLength uint32, keyLength uint32, parallelism uint8) (*Config, *ConfigError) {
-	config := &Config{
-		MaxMemory:     maxMemory,
-		MaxIterations: maxIterations,
-		Memory:        memory,
-		Iterations:    iterations,
-		SaltLength:    saltLength,
-		KeyLength:     keyLength,
-		Parallelism:   parallelism,
+// WithMaxMemory sets the max memory allowed for verification.
+func WithMaxMemory(memory MemorySize) Option {
+	return func(c *Config) { c.MaxMemory = memory }
+}
+
+// WithMaxIterations sets the max iterations allowed for verification.
+func WithMaxIterations(iterations uint32) Option {
+	return func(c *Config) { c.MaxIterations = iterations }
+}
+
+// WithMaxPasswordLength sets the longest password accepted, in bytes.
+func WithMaxPasswordLength(length uint32) Option {
+	return func(c *Config) { c.MaxPasswordLength = length }
+}
+
+// WithPreHash sets the pre-hash applied to passwords, e.g. WithPreHash(PreHashHMACSHA512V1).
+func WithPreHash(preHash PreHash) Option {
+	return func(c *Config) { c.PreHash = preHash }
+}
+
+// WithNormalization sets the Unicode normalization applied to passwords, e.g. WithNormalization(NormalizationNFKC).
+func WithNormalization(normalization Normalization) Option {
+	return func(c *Config) { c.Normalization = normalization }
+}
+
+// WithPreset sets all fields from preset, later options override them.
+func WithPreset(preset Preset) Option {
+	return func(c *Config) { *c = preset.config }
+}
+
+// NewConfig returns a validated Config built from opts, unset fields get the defaults:
+//
+//	config, err := argon2password.NewConfig(
+//		argon2password.WithMemory(128*argon2password.MiB),
+//		argon2password.WithIterations(4),
+//	)
+//
+// The Config is rejected if memory and iterations are below the OWASP minimum,
+// memory is below 8 KiB per lane as required by Argon2, memory or iterations exceed
+// their max, the salt or key is shorter than 16 or 32 bytes, or the pre-hash or normalization is unknown.
+// Memory below 1 MiB is reported as ErrConfigMemoryTooSmall, it is most likely
+// a size meant in MiB or MB.
+// Every invalid field is reported as a *ConfigError, joined with errors.Join.
+func NewConfig(opts ...Option) (*Config, error) {
+	config := &Config{}
+	for _, opt := range opts {
+		opt(config)
 	}
 
-	err := validateConfig(config)
-	if err != nil {
+	if err := validateConfig(config); err != nil {
 		return nil, err
 	}
 	return config, nil
@@ -93,76 +186,80 @@ func NewConfig(maxMemory uint32, maxIterations uint32, memory uint32, iterations
 
 // NewDefaultConfig returns a validated Config with recommended default values
 func NewDefaultConfig() (*Config, error) {
-	config, err := NewConfig(
-		ArgonMaxMemory,          // MaxMemory
-		ArgonMaxIterations,      // MaxIterations
-		ArgonMemory,             // Memory
-		ArgonIterations,         // Iterations
-		ArgonSaltLength,         // SaltLength
-		ArgonKeyLength,          // KeyLength
-		argonDefaultParallelism, // Parallelism
-	)
-	if err != nil {
-		return nil, err
-	}
-	return config, nil
+	return NewConfig()
 }
 
-func validateConfig(config *Config) *ConfigError {
+// validateConfig fills in the defaults of config and checks every field
+func validateConfig(config *Config) error {
 	if config == nil {
 		return ErrConfigNil
 	}
 
-	// Validates parallelism, and caps it to the number of CPUs
-	// Also makes sure the vlaue is within the allowed range
-	config.Parallelism = capParallelism(config.Parallelism)
-
-	// Check max memory
+	// Treats 0 values as "not set"
 	if config.MaxMemory == 0 {
 		config.MaxMemory = ArgonMaxMemory
 	}
-
-	if config.Memory == 0 { // Treats 0 value as "not set"
-		config.Memory = ArgonMaxMemory // Wont succeed uint32 max
-	}
-
-	// Check memory
-	switch {
-	case config.Memory == 0: // Treats 0 value as "not set"
-		config.Memory = ArgonMemory // safe, constants are verified at build time
-	case config.Memory > config.MaxMemory:
-		return ErrConfigMemoryExceedsMax
-	}
-
-	// Check max iterations
 	if config.MaxIterations == 0 {
 		config.MaxIterations = ArgonMaxIterations
 	}
-
-	// Check iterations
-	switch {
-	case config.Iterations == 0: // Treats 0 value as "not set"
-		config.Iterations = ArgonIterations // safe, constants are verified at build time
-	case config.Iterations > config.MaxIterations:
-		return ErrConfigIterationsExceedsMax
+	if config.Memory == 0 {
+		config.Memory = ArgonMemory
+	}
+	if config.Iterations == 0 {
+		config.Iterations = ArgonIterations
 	}
-
-	// Check salt length
 	if config.SaltLength == 0 {
 		config.SaltLength = ArgonSaltLength
 	}
-
-	// Check key length
 	if config.KeyLength == 0 {
 		config.KeyLength = ArgonKeyLength
 	}
+	if config.MaxPasswordLength == 0 {
+		config.MaxPasswordLength = ArgonMaxPasswordLength
+	}
 
-	return nil
+	// Validates parallelism, and caps values over ArgonMaxParallelism to the number of CPUs available to the process
+	config.Parallelism = capParallelism(config.Parallelism)
+
+	var errs []error
+	invalid := func(field string, value uint64, err error) {
+		errs = append(errs, &ConfigError{Field: field, Value: value, Err: err})
+	}
+
+	switch {
+	case config.Memory < argonMinMemory:
+		invalid("Memory", uint64(config.Memory), ErrConfigMemoryTooSmall)
+	case config.Memory > config.MaxMemory:
+		invalid("Memory", uint64(config.Memory), ErrConfigMemoryExceedsMax)
+	case !MeetsOWASPMinimum(config.Memory, config.Iterations):
+		invalid("Memory", uint64(config.Memory), ErrConfigBelowOWASPMinimum)
+	case config.Memory < MemorySize(config.Parallelism)*8*KiB:
+		invalid("Memory", uint64(config.Memory), ErrConfigMemoryParallelism)
+	}
+	if config.Iterations > config.MaxIterations {
+		invalid("Iterations", uint64(config.Iterations), ErrConfigIterationsExceedsMax)
+	}
+	if config.SaltLength < configMinSaltLength {
+		invalid("SaltLength", uint64(config.SaltLength), ErrConfigSaltTooShort)
+	}
+	if config.KeyLength < configMinKeyLength {
+		invalid("KeyLength", uint64(config.KeyLength), ErrConfigKeyTooShort)
+	}
+	if !validPreHash(config.PreHash) {
+		errs = append(errs, fmt.Errorf("%w: PreHash=%q", ErrUnsupportedPreHash, string(config.PreHash)))
+	}
+	if !validNormalization(config.Normalization) {
+		errs = append(errs, fmt.Errorf("%w: Normalization=%q", ErrUnsupportedNormalization, string(config.Normalization)))
+	}
+
+	return errors.Join(errs...)
 }
 
+// capParallelism returns the default parallelism for 0, and caps other values
+// at the number of CPUs available to the process. Values up to ArgonMaxParallelism
+// are kept, the parallelism is part of the hash and a preset such as
+// PresetRFC9106Second must not produce different hashes on a smaller machine.
 func capParallelism(input uint8) uint8 {
-	// Convert input to int only once
-
 	var u8Cpu uint8
 	switch {
 	case numCPU > uint8MaxValue:
@@ -174,9 +271,9 @@ func capParallelism(input uint8) uint8 {
 
 	switch {
 	case input == 0:
-		return ArgonMaxParallelism
-	case input > u8Cpu:
-		return u8Cpu
+		return argonDefaultParallelism
+	case input > max(u8Cpu, ArgonMaxParallelism):
+		return max(u8Cpu, ArgonMaxParallelism)
 	default:
 		return input
 	}
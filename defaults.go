@@ -0,0 +1,63 @@
+package argon2password
+
+import "sync/atomic"
+
+// defaultConfig holds the validated Config used by HashPW, ComparePW, CompareDummy
+// and the other functions without a Config argument. It is nil until SetDefaultConfig
+// is called, in which case builtinConfig is used.
+var defaultConfig atomic.Pointer[Config]
+
+// builtinConfig is the default Config from the package constants,
+// with the memory lowered to fit the process memory limit
+var builtinConfig = newBuiltinConfig()
+
+func newBuiltinConfig() *Config {
+	memory, iterations := defaultMemoryCost(memoryBudget, hasMemoryBudget)
+	return &Config{
+		Memory:            memory,
+		Iterations:        iterations,
+		Parallelism:       argonDefaultParallelism,
+		SaltLength:        ArgonSaltLength,
+		KeyLength:         ArgonKeyLength,
+		MaxMemory:         ArgonMaxMemory,
+		MaxIterations:     ArgonMaxIterations,
+		MaxPasswordLength: ArgonMaxPasswordLength,
+	}
+}
+
+// SetDefaultConfig replaces the parameters used by HashPW, ComparePW and the other
+// functions without a Config argument, e.g. with the result of Calibrate or ConfigFromEnv.
+// The config is validated like NewConfig and copied, later changes to it have no effect.
+// A nil config restores the built-in defaults: the package constants, with
+// the memory lowered when the process has a cgroup memory limit, see MaxConcurrentHashes.
+//
+// It is safe to call concurrently with hashing and comparing, e.g. on a live config
+// reload. Hashes in progress finish with the parameters they started with.
+// The DoS protection limits MaxMemory and MaxIterations also apply to ComparePW,
+// so raise them before verifying hashes created with larger parameters.
+func SetDefaultConfig(config *Config) error {
+	if config == nil {
+		defaultConfig.Store(nil)
+		return nil
+	}
+	validated := *config
+	if err := validateConfig(&validated); err != nil {
+		return err
+	}
+	defaultConfig.Store(&validated)
+	return nil
+}
+
+// DefaultConfig returns a copy of the parameters currently used by HashPW and ComparePW.
+func DefaultConfig() *Config {
+	config := *currentConfig()
+	return &config
+}
+
+// currentConfig returns the current default Config, which must not be modified
+func currentConfig() *Config {
+	if config := defaultConfig.Load(); config != nil {
+		return config
+	}
+	return builtinConfig
+}
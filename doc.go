@@ -4,13 +4,13 @@ package argon2password
 // using the Argon2id algorithm.
 //
 // Default Argon2id Parameters:
-//   - ArgonMemory: 64MB (64 * 1024)
+//   - ArgonMemory: 64 MiB (64 * MiB), memory is a MemorySize in KiB
 //   - ArgonIterations: 3 iterations for enhanced security
 //   - ArgonSaltLength: 16 bytes (128-bit)
 //   - ArgonKeyLength: 32 bytes (256-bit)
 //
 // DoS Protection Parameters:
-//   - ArgonMaxMemory: Max 512MB (512 * 1024)
+//   - ArgonMaxMemory: Max 512 MiB (512 * MiB)
 //   - ArgonMaxIterations: Max 10 iterations
 //
 // Parallelism:
@@ -21,12 +21,19 @@ package argon2password
 //
 // OWASP Recommended Configurations:
 // The current implementation uses higher memory than OWASP minimum recommendations.
-// All of these configurations provide equivalent protection:
-//   - 46 MiB memory, 1 iteration, 1 parallel thread (m=47104, t=1, p=1)
-//   - 19 MiB memory, 2 iterations, 1 parallel thread (m=19456, t=2, p=1)
-//   - 12 MiB memory, 3 iterations, 1 parallel thread (m=12288, t=3, p=1)
-//   - 9 MiB memory, 4 iterations, 1 parallel thread (m=9216, t=4, p=1)
-//   - 7 MiB memory, 5 iterations, 1 parallel thread (m=7168, t=5, p=1)
+// All of these configurations provide equivalent protection, and are available as presets:
+//   - PresetOWASP46M1T: 46 MiB memory, 1 iteration, 1 parallel thread (m=47104, t=1, p=1)
+//   - PresetOWASP19M2T: 19 MiB memory, 2 iterations, 1 parallel thread (m=19456, t=2, p=1)
+//   - PresetOWASP12M3T: 12 MiB memory, 3 iterations, 1 parallel thread (m=12288, t=3, p=1)
+//   - PresetOWASP9M4T: 9 MiB memory, 4 iterations, 1 parallel thread (m=9216, t=4, p=1)
+//   - PresetOWASP7M5T: 7 MiB memory, 5 iterations, 1 parallel thread (m=7168, t=5, p=1)
+//
+// RFC 9106 Recommended Configurations:
+//   - PresetRFC9106First: 2 GiB memory, 1 iteration, 4 lanes (m=2097152, t=1, p=4),
+//     above ArgonMaxMemory, verify with ComparePWWithConfig
+//   - PresetRFC9106Second: 64 MiB memory, 3 iterations, 4 lanes (m=65536, t=3, p=4)
+//
+// Presets lists them all and PresetByName looks one up by name.
 //
 // Password generation
 //
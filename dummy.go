@@ -0,0 +1,69 @@
+package argon2password
+
+import "sync/atomic"
+
+// dummy is a pre-generated hash for the Config it was created with
+type dummy struct {
+	config *Config
+	hash   []byte
+}
+
+// dummyHashes caches the dummy hash of the current default Config
+var dummyHashes atomic.Pointer[dummy]
+
+// dummyHash returns a pre-generated hash with the default parameters.
+// It holds a random salt and a random key, so no password can match it,
+// and it is created without running Argon2id on first use and again
+// after SetDefaultConfig changed the defaults.
+func dummyHash() ([]byte, error) {
+	config := currentConfig()
+	if d := dummyHashes.Load(); d != nil && d.config == config {
+		return d.hash, nil
+	}
+	salt, err := generateSalt(config.SaltLength)
+	if err != nil {
+		return nil, err
+	}
+	key, err := generateRandomBytes(config.KeyLength)
+	if err != nil {
+		return nil, err
+	}
+	hash := encodeArgonHashAsBytes(key, salt, config.Memory.KiB(), config.Iterations, config.Parallelism, config.Normalization, config.PreHash)
+	// Concurrent callers may each store a hash, any of them is valid
+	dummyHashes.Store(&dummy{config: config, hash: hash})
+	return hash, nil
+}
+
+// CompareDummy runs a full Argon2id verification of password against a
+// pre-generated hash using the default parameters, and always returns false.
+// The dummy hash follows SetDefaultConfig, so it costs the same as new hashes.
+// Use it when a user does not exist, so the code path costs the same as
+// comparing against a real stored hash and timing doesn't reveal which
+// accounts exist:
+//
+//	hash, found := lookup(username)
+//	if !found {
+//		return argon2password.CompareDummy(password)
+//	}
+//	return argon2password.ComparePW(password, hash)
+func CompareDummy(password string) (bool, error) {
+	passwordBytes := []byte(password)
+	defer clear(passwordBytes)
+	return CompareDummyBytes(passwordBytes)
+}
+
+// CompareDummyBytes is the []byte version of CompareDummy.
+func CompareDummyBytes(password []byte) (bool, error) {
+	if err := checkPasswordLength(password, 0); err != nil {
+		return false, err
+	}
+	hash, err := dummyHash()
+	if err != nil {
+		return false, err
+	}
+	// The result is discarded, only the cost of computing it matters
+	if _, err := compareArgonPasswordAndHash(password, hash); err != nil {
+		return false, err
+	}
+	return false, nil
+}
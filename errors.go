@@ -5,15 +5,20 @@ import "errors"
 // Password-related errors
 var (
 	// Argon2 specific errors
-	ErrInvalidHashFormat    = errors.New("argon2Password: Invalid hash format")
-	ErrUnsupportedAlgorithm = errors.New("argon2Password: Unsupported algorithm")
-	ErrInvalidVersion       = errors.New("argon2Password: Invalid argon2 version")
-	ErrInvalidParams        = errors.New("argon2Password: Invalid parameters in hash")
-	ErrInvalidSalt          = errors.New("argon2Password: Invalid salt in hash")
-	ErrInvalidHash          = errors.New("argon2Password: Invalid hash")
-	ErrHashTooLarge         = errors.New("argon2Password: Hash length exceeds supported limit")
-	ErrEmptyPassword        = errors.New("argon2Password: Password cannot be empty")
-	ErrNilHash              = errors.New("argon2Password: Hash is nil")
+	ErrInvalidHashFormat        = errors.New("argon2Password: Invalid hash format")
+	ErrUnsupportedAlgorithm     = errors.New("argon2Password: Unsupported algorithm")
+	ErrInvalidVersion           = errors.New("argon2Password: Invalid argon2 version")
+	ErrInvalidParams            = errors.New("argon2Password: Invalid parameters in hash")
+	ErrInvalidSalt              = errors.New("argon2Password: Invalid salt in hash")
+	ErrInvalidHash              = errors.New("argon2Password: Invalid hash")
+	ErrHashTooLarge             = errors.New("argon2Password: Hash length exceeds supported limit")
+	ErrEmptyPassword            = errors.New("argon2Password: Password cannot be empty")
+	ErrNilHash                  = errors.New("argon2Password: Hash is nil")
+	ErrPasswordTooLong          = errors.New("argon2Password: Password exceeds the maximum length")
+	ErrPasswordMaxLength        = errors.New("argon2Password: Maximum password length must be positive")
+	ErrUnsupportedPreHash       = errors.New("argon2Password: Unsupported pre-hash")
+	ErrUnsupportedNormalization = errors.New("argon2Password: Unsupported Unicode normalization")
+	ErrNISTMaxLength            = errors.New("argon2Password: NISTPolicy MaxLength must be at least 64")
 )
 
 // Overflow errors
@@ -32,6 +37,37 @@ var (
 	ErrNegativeLength   = errors.New("argon2Password: Length cannot be negative")
 )
 
+// Calibration errors
+var (
+	ErrCalibrationTarget    = errors.New("argon2Password: Calibration target must be positive")
+	ErrCalibrationMaxMemory = errors.New("argon2Password: Calibration max memory is below the 7 MiB minimum")
+	ErrCalibrationTooSlow   = errors.New("argon2Password: No parameters meeting the OWASP minimum fit within the calibration target")
+)
+
+// Audit errors
+var (
+	ErrAuditColumn = errors.New("argon2Password: Audit column cannot be negative")
+)
+
+// Config loading errors
+var (
+	ErrInvalidMemorySize = errors.New("argon2Password: Invalid memory size")
+	ErrMemorySizeUnit    = errors.New("argon2Password: Invalid memory size unit")
+	ErrConfigEnv         = errors.New("argon2Password: Invalid config environment variable")
+)
+
+// Legacy hash wrapping errors
+var (
+	ErrInvalidLegacyHash       = errors.New("argon2Password: Invalid legacy hash")
+	ErrUnsupportedLegacyScheme = errors.New("argon2Password: Unsupported legacy hash scheme")
+)
+
+// SecureBuffer errors
+var (
+	ErrSecureBufferSize      = errors.New("argon2Password: SecureBuffer size cannot be negative")
+	ErrSecureBufferDestroyed = errors.New("argon2Password: SecureBuffer has been destroyed")
+)
+
 // Random number generation errors
 var (
 	ErrRandomNumNegativeN = errors.New("argon2Password: n must be greater than 0")
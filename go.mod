@@ -2,6 +2,11 @@ module gopkg.hlmpn.dev/pkg/argon2password
 
 go 1.24.1
 
-require golang.org/x/crypto v0.36.0
+require (
+	golang.org/x/crypto v0.36.0
+	golang.org/x/term v0.30.0
+)
 
-require golang.org/x/sys v0.31.0 // indirect
+require golang.org/x/sys v0.31.0
+
+require golang.org/x/text v0.23.0
@@ -0,0 +1,74 @@
+package argon2password
+
+import "golang.org/x/crypto/argon2"
+
+// HashInfo describes the parameters of an encoded Argon2id hash.
+type HashInfo struct {
+	Algorithm     string `json:"algorithm"`
+	Version       int    `json:"version"`
+	Memory        uint32 `json:"memory"` // in KiB, as encoded in m=
+	Iterations    uint32 `json:"iterations"`
+	Parallelism   uint8  `json:"parallelism"`
+	SaltLength    int    `json:"salt_length"`             // in bytes
+	KeyLength     int    `json:"key_length"`              // in bytes
+	Wrapped       string `json:"wrapped,omitempty"`       // inner legacy scheme of a wrapped hash
+	PreHash       string `json:"pre_hash,omitempty"`      // pre-hash applied to the password, see PreHash
+	Normalization string `json:"normalization,omitempty"` // Unicode normalization applied to the password, see Normalization
+
+	// Set by ParseHash when the hash is over the MaxMemory or MaxIterations
+	// of DefaultConfig or LegacyMaxBcryptCost, ComparePW refuses to verify it
+	ExceedsMaxMemory     bool `json:"exceeds_max_memory,omitempty"`
+	ExceedsMaxIterations bool `json:"exceeds_max_iterations,omitempty"`
+	ExceedsMaxBcryptCost bool `json:"exceeds_max_bcrypt_cost,omitempty"`
+}
+
+// DecodeHash parses an encoded hash and returns its parameters.
+// The hash is validated the same way as in ComparePW, so a hash
+// that decodes without error can be verified.
+func DecodeHash(hash string) (*HashInfo, error) {
+	info, err := ParseHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if info.ExceedsMaxMemory || info.ExceedsMaxIterations || info.ExceedsMaxBcryptCost {
+		return nil, ErrInvalidParams
+	}
+	return info, nil
+}
+
+// ParseHash parses an encoded hash like DecodeHash, without enforcing the DoS
+// protection limits, e.g. to inspect a hash created with larger parameters.
+// The limits of DefaultConfig it exceeds are reported in the HashInfo.
+func ParseHash(hash string) (*HashInfo, error) {
+	encoded := []byte(hash)
+	var wrapped string
+	var exceedsBcryptCost bool
+	if IsWrappedHash(hash) {
+		params, argonHash, err := splitWrappedHash(encoded)
+		if err != nil {
+			return nil, err
+		}
+		encoded, wrapped, exceedsBcryptCost = argonHash, string(params.scheme), params.exceedsLimits()
+	}
+	h, err := parseArgonHashBytes(encoded)
+	if err != nil {
+		return nil, err
+	}
+	config := currentConfig()
+	info := &HashInfo{
+		Algorithm:            argon2id,
+		Version:              argon2.Version,
+		Memory:               h.memory,
+		Iterations:           h.iterations,
+		Parallelism:          h.parallelism,
+		SaltLength:           len(h.salt),
+		KeyLength:            len(h.hash),
+		Wrapped:              wrapped,
+		PreHash:              string(h.preHash),
+		Normalization:        string(h.normalization),
+		ExceedsMaxMemory:     MemorySize(h.memory) > config.MaxMemory,
+		ExceedsMaxIterations: h.iterations > config.MaxIterations,
+		ExceedsMaxBcryptCost: exceedsBcryptCost,
+	}
+	return info, nil
+}
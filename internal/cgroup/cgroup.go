@@ -0,0 +1,251 @@
+// Package cgroup reads the resource limits of the current process from
+// cgroup v1 and v2 filesystems, so container limits can be respected where
+// the runtime only reports the resources of the host.
+//
+// Every function takes the root filesystem as an fs.FS, os.DirFS("/") in
+// production, so the parsing can be tested against fake cgroup trees.
+// Missing or unreadable files are treated as "no limit".
+package cgroup
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Paths relative to the root filesystem
+const (
+	procSelfCgroup = "proc/self/cgroup"
+	mountRoot      = "sys/fs/cgroup"
+)
+
+// v1 controller mount points, relative to mountRoot
+var (
+	v1CPUMounts    = []string{"cpu,cpuacct", "cpuacct,cpu", "cpu"}
+	v1MemoryMounts = []string{"memory"}
+)
+
+// v1UnlimitedMemory is the smallest memory.limit_in_bytes treated as no limit,
+// cgroup v1 reports the largest page-aligned int64 when none is set
+const v1UnlimitedMemory = 1 << 62
+
+// membership is the cgroup path of the process, per hierarchy
+type membership struct {
+	// unified is the cgroup v2 path, empty if the process isn't in a v2 hierarchy
+	unified string
+	// controllers maps v1 controllers such as "cpu" to their cgroup path
+	controllers map[string]string
+}
+
+// readMembership parses /proc/self/cgroup, lines have the format
+//
+//	hierarchy-ID:controller-list:cgroup-path
+//
+// where v2 uses the hierarchy ID 0 and an empty controller list.
+func readMembership(fsys fs.FS) (membership, bool) {
+	f, err := fsys.Open(procSelfCgroup)
+	if err != nil {
+		return membership{}, false
+	}
+	defer f.Close()
+
+	m := membership{controllers: make(map[string]string)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3) //nolint:mnd // id, controllers, path
+		if len(parts) != 3 {                            //nolint:mnd //
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			m.unified = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			m.controllers[controller] = parts[2]
+		}
+	}
+	if scanner.Err() != nil {
+		return membership{}, false
+	}
+	return m, true
+}
+
+// CPULimit returns the CPU quota of the current process in whole CPUs,
+// rounded up so a quota of 1.5 CPUs gives 2, and false if there is no quota.
+//
+// With cgroup v2 the smallest cpu.max quota of the process's cgroup and its
+// ancestors is used. With cgroup v1 cpu.cfs_quota_us and cpu.cfs_period_us
+// of the cpu controller are used.
+func CPULimit(fsys fs.FS) (int, bool) {
+	m, ok := readMembership(fsys)
+	if !ok {
+		return 0, false
+	}
+	if m.unified != "" {
+		if n, ok := cpuLimitV2(fsys, m.unified); ok {
+			return n, true
+		}
+	}
+	if cgroupPath, ok := m.controllers["cpu"]; ok {
+		return cpuLimitV1(fsys, cgroupPath)
+	}
+	return 0, false
+}
+
+// AvailableCPUs returns the number of CPUs the process can use, the smaller of
+// maxProcs, runtime.GOMAXPROCS(0) in production, and the CPU quota, at least 1.
+// runtime.NumCPU reports the cores of the host, so a container limited to 1 CPU
+// on a 64 core node would otherwise get four lanes contending for a single CPU.
+func AvailableCPUs(fsys fs.FS, maxProcs int) int {
+	n := max(maxProcs, 1)
+	if limit, ok := CPULimit(fsys); ok && limit < n {
+		n = max(limit, 1)
+	}
+	return n
+}
+
+// cpuLimitV2 walks from the cgroup up to the root, taking the smallest cpu.max quota.
+// cpu.max has the format "$MAX $PERIOD", where $MAX is "max" without a quota.
+func cpuLimitV2(fsys fs.FS, cgroupPath string) (int, bool) {
+	limit, ok := minV2(fsys, cgroupPath, "cpu.max", func(fields []string) (uint64, bool) {
+		if len(fields) != 2 || fields[0] == "max" { //nolint:mnd // quota and period
+			return 0, false
+		}
+		n, ok := cpus(fields[0], fields[1])
+		return uint64(n), ok //nolint:gosec // G115, cpus is positive
+	})
+	return int(limit), ok //nolint:gosec // G115, from an int
+}
+
+// minV2 walks from the cgroup up to the root, returning the smallest value
+// of the file in the unified hierarchy, as the tightest ancestor limit applies
+func minV2(fsys fs.FS, cgroupPath, file string, parse func([]string) (uint64, bool)) (uint64, bool) {
+	var limit uint64
+	found := false
+	for _, dir := range v2Dirs(cgroupPath) {
+		fields, ok := readFields(fsys, path.Join(dir, file))
+		if !ok {
+			continue
+		}
+		n, ok := parse(fields)
+		if ok && (!found || n < limit) {
+			limit, found = n, true
+		}
+	}
+	return limit, found
+}
+
+// cpuLimitV1 reads the CFS quota and period of the cpu controller,
+// a quota of -1 means no limit
+func cpuLimitV1(fsys fs.FS, cgroupPath string) (int, bool) {
+	for _, dir := range v1Dirs(v1CPUMounts, cgroupPath) {
+		quota, ok := readFields(fsys, path.Join(dir, "cpu.cfs_quota_us"))
+		if !ok || len(quota) != 1 {
+			continue
+		}
+		period, ok := readFields(fsys, path.Join(dir, "cpu.cfs_period_us"))
+		if !ok || len(period) != 1 {
+			continue
+		}
+		if quota[0] == "-1" {
+			return 0, false
+		}
+		return cpus(quota[0], period[0])
+	}
+	return 0, false
+}
+
+// MemoryLimit returns the memory limit of the current process in bytes,
+// and false if there is no limit.
+//
+// With cgroup v2 the smallest memory.max of the process's cgroup and its
+// ancestors is used. With cgroup v1 memory.limit_in_bytes of the memory controller is used.
+func MemoryLimit(fsys fs.FS) (uint64, bool) {
+	m, ok := readMembership(fsys)
+	if !ok {
+		return 0, false
+	}
+	if m.unified != "" {
+		if n, ok := minV2(fsys, m.unified, "memory.max", parseMemory); ok {
+			return n, true
+		}
+	}
+	if cgroupPath, ok := m.controllers["memory"]; ok {
+		for _, dir := range v1Dirs(v1MemoryMounts, cgroupPath) {
+			fields, ok := readFields(fsys, path.Join(dir, "memory.limit_in_bytes"))
+			if !ok {
+				continue
+			}
+			if n, ok := parseMemory(fields); ok && n < v1UnlimitedMemory {
+				return n, true
+			}
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// parseMemory parses a limit in bytes, "max" means no limit
+func parseMemory(fields []string) (uint64, bool) {
+	if len(fields) != 1 || fields[0] == "max" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// cpus divides quota by period, rounding up
+func cpus(quota, period string) (int, bool) {
+	q, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseInt(period, 10, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return int((q + p - 1) / p), true
+}
+
+// v2Dirs returns the directories of the cgroup and its ancestors in the unified hierarchy.
+// Inside a container with its own cgroup namespace the path is "/" and the
+// limits are in the files at the mount root.
+func v2Dirs(cgroupPath string) []string {
+	cgroupPath = path.Clean("/" + cgroupPath)
+	var dirs []string
+	for {
+		dirs = append(dirs, path.Join(mountRoot, cgroupPath))
+		if cgroupPath == "/" {
+			return dirs
+		}
+		cgroupPath = path.Dir(cgroupPath)
+	}
+}
+
+// v1Dirs returns the candidate directories of a v1 controller, the cgroup path
+// under each mount point, then the mount point itself for containers that
+// mount their own cgroup at the root
+func v1Dirs(mounts []string, cgroupPath string) []string {
+	dirs := make([]string, 0, 2*len(mounts)) //nolint:mnd //
+	for _, mount := range mounts {
+		dirs = append(dirs, path.Join(mountRoot, mount, path.Clean("/"+cgroupPath)))
+	}
+	for _, mount := range mounts {
+		dirs = append(dirs, path.Join(mountRoot, mount))
+	}
+	return dirs
+}
+
+// readFields reads a small cgroup file and splits it on whitespace
+func readFields(fsys fs.FS, name string) ([]string, bool) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, false
+	}
+	return strings.Fields(string(data)), true
+}
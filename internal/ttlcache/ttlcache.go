@@ -0,0 +1,87 @@
+// Package ttlcache is a size-capped map whose entries expire after a fixed TTL,
+// shared by the basicauth verified-credential cache and the breach range cache.
+package ttlcache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache maps keys to values for a time. It is safe for concurrent use,
+// and a nil Cache is disabled: it never holds anything.
+type Cache[K comparable, V any] struct {
+	ttl     time.Duration
+	size    int
+	mu      sync.Mutex
+	entries map[K]entry[V]
+}
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// New returns an empty Cache keeping at most size entries for ttl each.
+func New[K comparable, V any](ttl time.Duration, size int) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[K]entry[V], size),
+	}
+}
+
+// Get returns the value of key if it hasn't expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	if !time.Now().Before(e.expires) {
+		delete(c.entries, key)
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Add stores value under key for the TTL, evicting expired entries first
+// and an arbitrary one if the cache is still full.
+func (c *Cache[K, V]) Add(key K, value V) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		for existing, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, existing)
+			}
+		}
+		if len(c.entries) >= c.size {
+			for existing := range c.entries {
+				delete(c.entries, existing)
+				break
+			}
+		}
+	}
+	c.entries[key] = entry[V]{value: value, expires: now.Add(c.ttl)}
+}
+
+// Len returns the number of entries, including expired ones not evicted yet.
+func (c *Cache[K, V]) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,234 @@
+package argon2password
+
+import (
+	"bytes"
+	"crypto/md5"  //nolint:gosec // G501, only used to recompute legacy hashes
+	"crypto/sha1" //nolint:gosec // G505, only used to recompute legacy hashes
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// LegacyScheme identifies the inner scheme of a wrapped hash.
+type LegacyScheme string
+
+// Legacy schemes that can be wrapped in argon2id
+const (
+	LegacyBcrypt LegacyScheme = "bcrypt" // $2a$, $2b$ and $2y$ hashes
+	LegacyMD5    LegacyScheme = "md5"    // unsalted hex digest
+	LegacySHA1   LegacyScheme = "sha1"   // unsalted hex digest
+	LegacySHA256 LegacyScheme = "sha256" // unsalted hex digest
+	LegacySHA512 LegacyScheme = "sha512" // unsalted hex digest
+)
+
+// bcrypt constants, see golang.org/x/crypto/bcrypt
+const (
+	bcryptSaltLength    = 22 // encoded salt length
+	bcryptHashLength    = 31 // encoded hash length
+	bcryptPrefixLength  = 7  // "$2b$10$"
+	bcryptMinCost       = 4
+	bcryptMaxCost       = 31
+	bcryptEncryptedSize = 23 // only 23 of the 24 encrypted bytes are encoded
+	bcryptEncryptRounds = 64
+)
+
+// bcryptEncoding is the base64 alphabet used by bcrypt
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// bcryptMagic is "OrpheanBeholderScryDoubt", encrypted 64 times by bcrypt
+var bcryptMagic = []byte("OrpheanBeholderScryDoubt")
+
+// legacyParams holds what is needed to recompute a legacy hash from a password
+type legacyParams struct {
+	scheme LegacyScheme
+	minor  byte   // bcrypt: a, b or y
+	cost   int    // bcrypt cost
+	salt   []byte // bcrypt: encoded salt
+}
+
+// detectLegacyScheme returns the scheme of a legacy hash that can be wrapped
+func detectLegacyScheme(legacyHash string) (LegacyScheme, bool) {
+	switch {
+	case strings.HasPrefix(legacyHash, "$2a$"), strings.HasPrefix(legacyHash, "$2b$"), strings.HasPrefix(legacyHash, "$2y$"):
+		return LegacyBcrypt, true
+	case !isHex(legacyHash):
+		return "", false
+	}
+	switch scheme := legacyHexDigests[len(legacyHash)]; scheme {
+	case string(LegacyMD5), string(LegacySHA1), string(LegacySHA256), string(LegacySHA512):
+		return LegacyScheme(scheme), true
+	}
+	return "", false
+}
+
+// parseLegacyHash validates legacyHash for scheme and returns its parameters
+// along with the canonical form that is wrapped in argon2id
+func parseLegacyHash(legacyHash string, scheme LegacyScheme) (*legacyParams, []byte, error) {
+	switch scheme {
+	case LegacyMD5, LegacySHA1, LegacySHA256, LegacySHA512:
+		if !isHex(legacyHash) || len(legacyHash) != hex.EncodedLen(legacyDigestSize(scheme)) {
+			return nil, nil, ErrInvalidLegacyHash
+		}
+		return &legacyParams{scheme: scheme}, []byte(strings.ToLower(legacyHash)), nil
+	case LegacyBcrypt:
+		if len(legacyHash) != bcryptPrefixLength+bcryptSaltLength+bcryptHashLength ||
+			legacyHash[0] != dollarSignByte || legacyHash[3] != dollarSignByte || legacyHash[6] != dollarSignByte {
+			return nil, nil, ErrInvalidLegacyHash
+		}
+		params, err := parseBcryptParams(legacyHash[2:3], legacyHash[4:6], legacyHash[7:7+bcryptSaltLength])
+		if err != nil {
+			return nil, nil, err
+		}
+		return params, []byte(legacyHash), nil
+	default:
+		return nil, nil, ErrUnsupportedLegacyScheme
+	}
+}
+
+func parseBcryptParams(minor, cost, salt string) (*legacyParams, error) {
+	if len(minor) != 1 || !strings.Contains("aby", minor) {
+		return nil, ErrInvalidLegacyHash
+	}
+	c, err := strconv.Atoi(cost)
+	if err != nil || c < bcryptMinCost || c > bcryptMaxCost {
+		return nil, ErrInvalidLegacyHash
+	}
+	decoded, err := bcryptEncoding.DecodeString(salt)
+	if err != nil || len(salt) != bcryptSaltLength || bcryptEncoding.EncodeToString(decoded) != salt {
+		return nil, ErrInvalidLegacyHash
+	}
+	return &legacyParams{scheme: LegacyBcrypt, minor: minor[0], cost: c, salt: []byte(salt)}, nil
+}
+
+// exceedsLimits reports whether recomputing the legacy hash would cost more than LegacyMaxBcryptCost
+func (p *legacyParams) exceedsLimits() bool {
+	return p.scheme == LegacyBcrypt && p.cost > LegacyMaxBcryptCost
+}
+
+// encode returns the inner scheme segment of a wrapped hash,
+// e.g. "sha1" or "bcrypt,2b,10,<salt>"
+func (p *legacyParams) encode() []byte {
+	if p.scheme != LegacyBcrypt {
+		return []byte(p.scheme)
+	}
+	b := make([]byte, 0, len(LegacyBcrypt)+bcryptPrefixLength+bcryptSaltLength)
+	b = append(b, LegacyBcrypt...)
+	b = append(b, ',', '2', p.minor, ',')
+	b = p.appendCost(b)
+	b = append(b, ',')
+	return append(b, p.salt...)
+}
+
+// appendCost appends the bcrypt cost as two digits
+func (p *legacyParams) appendCost(b []byte) []byte {
+	if p.cost < 10 { //nolint:mnd // costs are always two digits
+		b = append(b, '0')
+	}
+	return strconv.AppendInt(b, int64(p.cost), 10) //nolint:mnd
+}
+
+// decodeLegacyParams parses the inner scheme segment of a wrapped hash
+func decodeLegacyParams(segment []byte) (*legacyParams, error) {
+	fields := strings.Split(string(segment), ",")
+	scheme := LegacyScheme(fields[0])
+	switch scheme {
+	case LegacyMD5, LegacySHA1, LegacySHA256, LegacySHA512:
+		if len(fields) != 1 {
+			return nil, ErrInvalidHashFormat
+		}
+		return &legacyParams{scheme: scheme}, nil
+	case LegacyBcrypt:
+		if len(fields) != 4 || len(fields[1]) != 2 || fields[1][0] != '2' || len(fields[2]) != 2 { //nolint:mnd // "bcrypt,2b,10,salt"
+			return nil, ErrInvalidHashFormat
+		}
+		return parseBcryptParams(fields[1][1:], fields[2], fields[3])
+	default:
+		return nil, ErrUnsupportedLegacyScheme
+	}
+}
+
+// compute recomputes the legacy hash of password in its canonical form
+func (p *legacyParams) compute(password []byte) ([]byte, error) {
+	var digest []byte
+	switch p.scheme {
+	case LegacyMD5:
+		sum := md5.Sum(password) //nolint:gosec // G401, legacy scheme
+		digest = sum[:]
+	case LegacySHA1:
+		sum := sha1.Sum(password) //nolint:gosec // G401, legacy scheme
+		digest = sum[:]
+	case LegacySHA256:
+		sum := sha256.Sum256(password)
+		digest = sum[:]
+	case LegacySHA512:
+		sum := sha512.Sum512(password)
+		digest = sum[:]
+	case LegacyBcrypt:
+		return p.bcrypt(password)
+	default:
+		return nil, ErrUnsupportedLegacyScheme
+	}
+	encoded := make([]byte, hex.EncodedLen(len(digest)))
+	hex.Encode(encoded, digest)
+	clear(digest)
+	return encoded, nil
+}
+
+// bcrypt computes "$2<minor>$<cost>$<salt><hash>" for password, the same way
+// golang.org/x/crypto/bcrypt does. That package has no API to hash with a given salt.
+func (p *legacyParams) bcrypt(password []byte) ([]byte, error) {
+	salt, err := bcryptEncoding.DecodeString(string(p.salt))
+	if err != nil {
+		return nil, ErrInvalidLegacyHash
+	}
+
+	// C implementations include the trailing NUL of the key string in the expansion
+	key := make([]byte, len(password)+1)
+	copy(key, password)
+	defer clear(key)
+
+	c, err := blowfish.NewSaltedCipher(key, salt)
+	if err != nil {
+		return nil, ErrInvalidLegacyHash
+	}
+	for range uint64(1) << p.cost {
+		blowfish.ExpandKey(key, c)
+		blowfish.ExpandKey(salt, c)
+	}
+
+	data := bytes.Clone(bcryptMagic)
+	for i := 0; i < len(data); i += blowfish.BlockSize {
+		for range bcryptEncryptRounds {
+			c.Encrypt(data[i:i+blowfish.BlockSize], data[i:i+blowfish.BlockSize])
+		}
+	}
+
+	out := make([]byte, 0, bcryptPrefixLength+bcryptSaltLength+bcryptHashLength)
+	out = append(out, '$', '2', p.minor, '$')
+	out = p.appendCost(out)
+	out = append(out, '$')
+	out = append(out, p.salt...)
+	out = bcryptEncoding.AppendEncode(out, data[:bcryptEncryptedSize])
+	clear(data)
+	return out, nil
+}
+
+func legacyDigestSize(scheme LegacyScheme) int {
+	switch scheme {
+	case LegacyMD5:
+		return md5.Size
+	case LegacySHA1:
+		return sha1.Size
+	case LegacySHA256:
+		return sha256.Size
+	case LegacySHA512:
+		return sha512.Size
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,33 @@
+package argon2password
+
+import "fmt"
+
+// PasswordTooLongError is returned for passwords longer than the maximum length,
+// before any hashing work is done. errors.Is(err, ErrPasswordTooLong) reports true for it.
+type PasswordTooLongError struct {
+	// Length of the password, or of the input read so far when reading from an io.Reader
+	Length int
+	// Max is the maximum length in bytes
+	Max int
+}
+
+func (e *PasswordTooLongError) Error() string {
+	return fmt.Sprintf("%v (%d bytes, max %d)", ErrPasswordTooLong, e.Length, e.Max)
+}
+
+// Is makes errors.Is(err, ErrPasswordTooLong) match a PasswordTooLongError.
+func (e *PasswordTooLongError) Is(target error) bool {
+	return target == ErrPasswordTooLong
+}
+
+// checkPasswordLength rejects passwords over maxLength bytes,
+// 0 uses the MaxPasswordLength of the default Config
+func checkPasswordLength(password []byte, maxLength uint32) error {
+	if maxLength == 0 {
+		maxLength = currentConfig().MaxPasswordLength
+	}
+	if uint64(len(password)) > uint64(maxLength) {
+		return &PasswordTooLongError{Length: len(password), Max: int(maxLength)}
+	}
+	return nil
+}
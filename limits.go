@@ -0,0 +1,80 @@
+package argon2password
+
+import (
+	"io/fs"
+	"math"
+	"os"
+	"runtime/debug"
+
+	"gopkg.hlmpn.dev/pkg/argon2password/internal/cgroup"
+)
+
+const (
+	// memoryBudgetDivisor is the share of the process memory limit given to Argon2id,
+	// the rest is left for the application and for garbage not yet collected
+	memoryBudgetDivisor = 2
+	bytesPerKiB         = 1024
+)
+
+// memoryBudget is the memory available to concurrent Argon2id computations,
+// half of the cgroup memory limit or GOMEMLIMIT, read once at init.
+// hasMemoryBudget is false when the process has no memory limit.
+var memoryBudget, hasMemoryBudget = readMemoryBudget(os.DirFS("/"))
+
+// readMemoryBudget returns the memory budget from the cgroup memory.max or
+// memory.limit_in_bytes, and GOMEMLIMIT if it is lower
+func readMemoryBudget(root fs.FS) (MemorySize, bool) {
+	limit, ok := cgroup.MemoryLimit(root)
+	// A negative input reads the limit without changing it
+	if goLimit := debug.SetMemoryLimit(-1); goLimit > 0 && goLimit < math.MaxInt64 {
+		if !ok || uint64(goLimit) < limit {
+			limit, ok = uint64(goLimit), true
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	budget := limit / bytesPerKiB / memoryBudgetDivisor
+	return MemorySize(min(budget, math.MaxUint32)), true //nolint:gosec // G115, capped at uint32
+}
+
+// defaultMemoryCost returns the default memory and iterations within budget.
+// Without a limit, or if ArgonMemory fits, they are ArgonMemory and ArgonIterations.
+// Otherwise they are lowered to the largest OWASP minimum that fits, keeping at least
+// ArgonIterations, so a 64 MiB container hashes with m=19 MiB,t=3 instead of 64 MiB.
+// The smallest OWASP minimum, 7 MiB, is used even if it doesn't fit,
+// as going lower would fall below the OWASP recommendation.
+func defaultMemoryCost(budget MemorySize, limited bool) (MemorySize, uint32) {
+	if !limited || ArgonMemory <= budget {
+		return ArgonMemory, ArgonIterations
+	}
+	for _, preset := range owaspPresets {
+		if preset.config.Memory <= budget {
+			return preset.config.Memory, max(preset.config.Iterations, ArgonIterations)
+		}
+	}
+	smallest := owaspPresets[len(owaspPresets)-1].config
+	return smallest.Memory, max(smallest.Iterations, ArgonIterations)
+}
+
+// MaxConcurrentHashes returns how many hashes with the default memory can run at once
+// within half of the process memory limit, at least 1, or 0 if the process has no
+// cgroup memory limit or GOMEMLIMIT. The limit is read at init, the default memory
+// follows SetDefaultConfig. Use it to size worker pools or semaphores around
+// HashPW and ComparePW, basicauth uses it for its default concurrency.
+//
+// A 256 MiB container gets 2 with the default 64 MiB, so four concurrent logins
+// can't exhaust its memory.
+func MaxConcurrentHashes() int {
+	return maxConcurrentHashes(memoryBudget, hasMemoryBudget, currentConfig().Memory)
+}
+
+func maxConcurrentHashes(budget MemorySize, limited bool, memory MemorySize) int {
+	if !limited {
+		return 0
+	}
+	if memory == 0 {
+		return 1
+	}
+	return max(int(budget/memory), 1)
+}
@@ -0,0 +1,138 @@
+package argon2password
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Environment variable names read by ConfigFromEnv, after the prefix
+const (
+	envMemory        = "MEMORY"
+	envIterations    = "ITERATIONS"
+	envParallelism   = "PARALLELISM"
+	envSaltLength    = "SALT_LENGTH"
+	envKeyLength     = "KEY_LENGTH"
+	envMaxMemory     = "MAX_MEMORY"
+	envMaxIterations = "MAX_ITERATIONS"
+	envMaxPassword   = "MAX_PASSWORD_LENGTH"
+	envPreHash       = "PRE_HASH"
+	envNormalization = "NORMALIZATION"
+)
+
+// ConfigFromEnv reads a Config from environment variables and validates it like NewConfig.
+// With the prefix "ARGON2_" the variables are:
+//
+//	ARGON2_MEMORY=64MiB       # a size with a unit, or a number in KiB
+//	ARGON2_ITERATIONS=3
+//	ARGON2_PARALLELISM=4
+//	ARGON2_SALT_LENGTH=16
+//	ARGON2_KEY_LENGTH=32
+//	ARGON2_MAX_MEMORY=512MiB
+//	ARGON2_MAX_ITERATIONS=10
+//	ARGON2_MAX_PASSWORD_LENGTH=4096
+//	ARGON2_PRE_HASH=hmac-sha512-v1  # optional, see PreHash
+//	ARGON2_NORMALIZATION=nfc        # optional, see Normalization
+//
+// Unset or empty variables get the defaults. This is the format
+// printed by "argon2password calibrate -format env".
+func ConfigFromEnv(prefix string) (*Config, error) {
+	return configFromLookup(prefix, os.LookupEnv)
+}
+
+func configFromLookup(prefix string, lookup func(string) (string, bool)) (*Config, error) {
+	config := &Config{}
+
+	memorySizes := []struct {
+		name string
+		dst  *MemorySize
+	}{
+		{name: envMemory, dst: &config.Memory},
+		{name: envMaxMemory, dst: &config.MaxMemory},
+	}
+	for _, v := range memorySizes {
+		value, ok := lookup(prefix + v.name)
+		if !ok || value == "" {
+			continue
+		}
+		size, err := ParseMemorySize(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s%s: %w", ErrConfigEnv, prefix, v.name, err)
+		}
+		*v.dst = size
+	}
+
+	numbers := []struct {
+		name string
+		bits int
+		set  func(uint64)
+	}{
+		{name: envIterations, bits: 32, set: func(n uint64) { config.Iterations = uint32(n) }},         //nolint:gosec // G115, parsed with 32 bits
+		{name: envSaltLength, bits: 32, set: func(n uint64) { config.SaltLength = uint32(n) }},         //nolint:gosec // G115
+		{name: envKeyLength, bits: 32, set: func(n uint64) { config.KeyLength = uint32(n) }},           //nolint:gosec // G115
+		{name: envMaxIterations, bits: 32, set: func(n uint64) { config.MaxIterations = uint32(n) }},   //nolint:gosec // G115
+		{name: envMaxPassword, bits: 32, set: func(n uint64) { config.MaxPasswordLength = uint32(n) }}, //nolint:gosec // G115
+		{name: envParallelism, bits: 8, set: func(n uint64) { config.Parallelism = uint8(n) }},         //nolint:gosec // G115, parsed with 8 bits
+	}
+	for _, v := range numbers {
+		value, ok := lookup(prefix + v.name)
+		if !ok || value == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, v.bits)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s%s=%q", ErrConfigEnv, prefix, v.name, value)
+		}
+		v.set(n)
+	}
+
+	if value, ok := lookup(prefix + envPreHash); ok {
+		config.PreHash = PreHash(value)
+	}
+	if value, ok := lookup(prefix + envNormalization); ok {
+		config.Normalization = Normalization(value)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// plainConfig has the fields of Config without its methods,
+// so it can be decoded without recursing into the Config unmarshalers
+type plainConfig Config
+
+// UnmarshalJSON decodes a Config and validates it like NewConfig,
+// unset fields get the defaults. Memory sizes can be given with a unit:
+//
+//	{"memory": "64MiB", "iterations": 3, "parallelism": 4}
+func (c *Config) UnmarshalJSON(data []byte) error {
+	plain := plainConfig(*c)
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return err //nolint:wrapcheck // a JSON syntax or type error
+	}
+	return c.setValidated(Config(plain))
+}
+
+// UnmarshalYAML decodes a Config and validates it like NewConfig.
+// It implements the unmarshaler interface of gopkg.in/yaml.v2 and v3, without depending on them:
+//
+//	memory: 64MiB
+//	iterations: 3
+func (c *Config) UnmarshalYAML(unmarshal func(any) error) error {
+	plain := plainConfig(*c)
+	if err := unmarshal(&plain); err != nil {
+		return err //nolint:wrapcheck // returned by the YAML decoder
+	}
+	return c.setValidated(Config(plain))
+}
+
+func (c *Config) setValidated(config Config) error {
+	if err := validateConfig(&config); err != nil {
+		return err
+	}
+	*c = config
+	return nil
+}
@@ -0,0 +1,103 @@
+package argon2password
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MemorySize is an amount of memory in KiB, the unit Argon2id and the
+// encoded m= parameter use. Write sizes with the unit constants,
+// e.g. 64 * MiB, so the unit is explicit where it is set.
+type MemorySize uint32
+
+// Memory units
+const (
+	KiB MemorySize = 1
+	MiB MemorySize = 1024 * KiB
+	GiB MemorySize = 1024 * MiB
+)
+
+// KiB returns the size in KiB, as passed to Argon2id.
+func (m MemorySize) KiB() uint32 {
+	return uint32(m)
+}
+
+// String formats the size in the largest unit that divides it, e.g. "64 MiB" or "1536 KiB".
+func (m MemorySize) String() string {
+	switch {
+	case m != 0 && m%GiB == 0:
+		return strconv.FormatUint(uint64(m/GiB), 10) + " GiB"
+	case m != 0 && m%MiB == 0:
+		return strconv.FormatUint(uint64(m/MiB), 10) + " MiB"
+	default:
+		return strconv.FormatUint(uint64(m), 10) + " KiB"
+	}
+}
+
+// ParseMemorySize parses a size such as "64MiB", "64 MiB", "1GiB" or "65536KiB".
+// A number without a unit is in KiB, like the encoded m= parameter.
+// Decimal units (KB, MB, GB) are rejected as they are a common source of unit mistakes.
+func ParseMemorySize(s string) (MemorySize, error) {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMemorySize, s)
+	}
+	n, err := strconv.ParseUint(s[:end], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMemorySize, s)
+	}
+
+	var unit MemorySize
+	switch strings.ToLower(strings.TrimSpace(s[end:])) {
+	case "", "kib":
+		unit = KiB
+	case "mib":
+		unit = MiB
+	case "gib":
+		unit = GiB
+	case "kb", "mb", "gb", "k", "m", "g":
+		return 0, fmt.Errorf("%w: %q, use KiB, MiB or GiB", ErrMemorySizeUnit, s)
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrMemorySizeUnit, s)
+	}
+	if n > uint64(^uint32(0)/uint32(unit)) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMemorySize, s)
+	}
+	return MemorySize(n) * unit, nil
+}
+
+// MarshalText formats the size like String, e.g. "64 MiB".
+func (m MemorySize) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText parses a size with ParseMemorySize.
+func (m *MemorySize) UnmarshalText(text []byte) error {
+	size, err := ParseMemorySize(string(text))
+	if err != nil {
+		return err
+	}
+	*m = size
+	return nil
+}
+
+// UnmarshalJSON accepts a string with a unit, e.g. "64MiB", or a number in KiB.
+func (m *MemorySize) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err //nolint:wrapcheck // a JSON syntax error
+		}
+		return m.UnmarshalText([]byte(s))
+	}
+	return m.UnmarshalText(data)
+}
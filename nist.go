@@ -0,0 +1,286 @@
+package argon2password
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NIST SP 800-63B password lengths, in Unicode code points
+const (
+	NISTMinLength             = 8  // when the password is used with another authentication factor
+	NISTMinLengthSingleFactor = 15 // when the password is the only authentication factor
+	NISTMinMaxLength          = 64 // smallest maximum length a verifier may set
+)
+
+// Violation codes reported by NISTPolicy
+const (
+	ViolationBlocklisted     ViolationCode = "blocklisted"
+	ViolationRepetitive      ViolationCode = "repetitive"
+	ViolationSequential      ViolationCode = "sequential"
+	ViolationContextSpecific ViolationCode = "context_specific"
+)
+
+// PasswordChecker checks a password against a policy or a list of known passwords.
+// It returns the rules the password fails, nil if it passes. The error is reserved
+// for failures of the checker itself (I/O, network errors, etc.), a rejected
+// password is not an error.
+type PasswordChecker interface {
+	Check(ctx context.Context, password string) ([]Violation, error)
+}
+
+// PasswordCheckerFunc adapts a plain function to a PasswordChecker.
+type PasswordCheckerFunc func(ctx context.Context, password string) ([]Violation, error)
+
+// Check calls f(ctx, password).
+func (f PasswordCheckerFunc) Check(ctx context.Context, password string) ([]Violation, error) {
+	return f(ctx, password)
+}
+
+// NISTPolicy checks passwords following NIST SP 800-63B instead of composition rules:
+// a minimum length counted in Unicode code points, long passphrases allowed, no
+// required character classes, and no blocklisted, repetitive, sequential or
+// context-specific passwords. The zero value requires 8 code points and checks
+// the built-in blocklist, the 1,751 passwords of 8 or more characters of the
+// zxcvbn top password list in common_passwords.txt, also embedded as
+// blocklist.Common. It is small, add a larger list or a breached password
+// corpus with Checkers:
+//
+//	policy := argon2password.NISTPolicy{MinLength: argon2password.NISTMinLengthSingleFactor}
+//	violations, err := policy.WithContextWords(username, email, "example.com").Check(ctx, password)
+//
+// Blocklist and context words are compared case-insensitively after NFKC normalization.
+type NISTPolicy struct {
+	// Minimum length in code points, defaults to NISTMinLength if unset(0).
+	MinLength int
+
+	// Maximum length in code points, unlimited if unset(0). NIST requires at
+	// least 64, Check fails with ErrNISTMaxLength below that. The hashing
+	// functions also reject passwords over the MaxPasswordLength of their Config in bytes.
+	MaxLength int
+
+	// Blocklist of passwords rejected in addition to the built-in list of common passwords.
+	Blocklist []string
+
+	// ContextWords are rejected when the password contains them, e.g. the service name,
+	// the username or the email address. Words shorter than 3 code points are ignored,
+	// words are also split at non-alphanumeric characters into parts of 4 or more.
+	ContextWords []string
+
+	// Checkers are run when the password passes the other rules, e.g. a breached
+	// password list. Their violations are appended to the result.
+	Checkers []PasswordChecker
+}
+
+// WithContextWords returns a copy of p with words added to ContextWords,
+// for the username and email address of the current user.
+func (p NISTPolicy) WithContextWords(words ...string) *NISTPolicy {
+	p.ContextWords = append(append([]string(nil), p.ContextWords...), words...)
+	return &p
+}
+
+// Check returns every rule password fails, or nil if it passes. The Checkers are
+// only run when the other rules pass, so a rejected password isn't sent anywhere.
+// A MaxLength below NISTMinMaxLength is a mistake in the policy and fails every check.
+func (p *NISTPolicy) Check(ctx context.Context, password string) ([]Violation, error) {
+	if p.MaxLength > 0 && p.MaxLength < NISTMinMaxLength {
+		return nil, fmt.Errorf("%w: MaxLength=%d", ErrNISTMaxLength, p.MaxLength)
+	}
+	violations := p.checkLocal(password)
+	if len(violations) > 0 {
+		return violations, nil
+	}
+	for _, checker := range p.Checkers {
+		v, err := checker.Check(ctx, password)
+		if err != nil {
+			return violations, fmt.Errorf("argon2Password: password check failed: %w", err)
+		}
+		violations = append(violations, v...)
+	}
+	return violations, nil
+}
+
+// checkLocal checks the rules that don't need the Checkers
+func (p *NISTPolicy) checkLocal(password string) []Violation {
+	minLength := p.MinLength
+	if minLength <= 0 {
+		minLength = NISTMinLength
+	}
+	violations := Validate(password, PasswordRequirements{MinLength: minLength, MaxLength: p.MaxLength})
+
+	folded := foldPassword(password)
+	repetitive, sequential := isRepetitive(folded), isSequential(folded)
+	// The built-in list also holds repetitive and sequential passwords such as
+	// "12345678", those are reported with the more specific code alone
+	if isBlocklisted(folded, p.Blocklist, !repetitive && !sequential) {
+		violations = append(violations, Violation{
+			Code:    ViolationBlocklisted,
+			Message: "Password is too common",
+		})
+	}
+	if repetitive {
+		violations = append(violations, Violation{
+			Code:    ViolationRepetitive,
+			Message: "Password must not be a repeated character or pattern",
+		})
+	}
+	if sequential {
+		violations = append(violations, Violation{
+			Code:    ViolationSequential,
+			Message: "Password must not be a sequence like 12345678 or abcdefgh",
+		})
+	}
+	if containsContextWord(folded, p.ContextWords) {
+		violations = append(violations, Violation{
+			Code:    ViolationContextSpecific,
+			Message: "Password must not contain the name of the service, your username or your email address",
+		})
+	}
+	return violations
+}
+
+// foldPassword normalizes s for case-insensitive comparisons
+func foldPassword(s string) string {
+	return strings.ToLower(norm.NFKC.String(s))
+}
+
+// commonPasswordList is the built-in blocklist, common passwords of 8 or more
+// characters one per line in rank order, see common_passwords.LICENSE. The blocklist package embeds a Bloom filter of the
+// same file, so both check the same words.
+//
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords is commonPasswordList folded like the passwords, built once
+var commonPasswords = sync.OnceValue(func() map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, word := range strings.Split(commonPasswordList, "\n") {
+		if word != "" {
+			words[foldPassword(word)] = struct{}{}
+		}
+	}
+	return words
+})
+
+// isBlocklisted reports whether folded is in blocklist, or with common a common password
+func isBlocklisted(folded string, blocklist []string, common bool) bool {
+	if _, ok := commonPasswords()[folded]; common && ok {
+		return true
+	}
+	for _, b := range blocklist {
+		if folded == foldPassword(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRepetitive reports whether s repeats a single character or a shorter pattern, e.g. "aaaaaaaa" or "abcabcabc"
+func isRepetitive(s string) bool {
+	runes := []rune(s)
+	n := len(runes)
+	for period := 1; period <= n/2; period++ {
+		if n%period != 0 {
+			continue
+		}
+		repeats := true
+		for i := period; i < n; i++ {
+			if runes[i] != runes[i-period] {
+				repeats = false
+				break
+			}
+		}
+		if repeats {
+			return true
+		}
+	}
+	return false
+}
+
+// keyboardRows are sequences typed by running a finger along a keyboard
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "qwertzuiop", "azertyuiop", "yxcvbnm", "wxcvbn"}
+
+// minSequenceRun is the shortest run counted as part of a sequence
+const minSequenceRun = 3
+
+// isSequential reports whether s consists of ascending or descending runs of
+// at least 3 code points or keyboard keys, e.g. "12345678", "87654321", "1234abcd" or "qwertyui"
+func isSequential(s string) bool {
+	runes := []rune(s)
+	if len(runes) < minSequenceRun {
+		return false
+	}
+	for i := 0; i < len(runes); {
+		run := sequenceRun(runes[i:])
+		if run < minSequenceRun {
+			return false
+		}
+		i += run
+	}
+	return true
+}
+
+// sequenceRun returns the length of the longest sequence at the start of runes
+func sequenceRun(runes []rune) int {
+	longest := 1
+	for _, step := range []rune{1, -1} {
+		n := 1
+		for n < len(runes) && runes[n]-runes[n-1] == step {
+			n++
+		}
+		longest = max(longest, n)
+	}
+	for _, row := range keyboardRows {
+		for _, keys := range []string{row, reverseString(row)} {
+			start := strings.IndexRune(keys, runes[0])
+			if start < 0 {
+				continue
+			}
+			keys = keys[start:]
+			n := 0
+			for n < len(runes) && n < len(keys) && runes[n] == rune(keys[n]) {
+				n++
+			}
+			longest = max(longest, n)
+		}
+	}
+	return longest
+}
+
+// reverseString reverses an ASCII string
+func reverseString(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// minContextWordLength and minContextPartLength are the shortest context words and parts checked
+const (
+	minContextWordLength = 3
+	minContextPartLength = 4
+)
+
+// containsContextWord reports whether folded contains one of words, or a part of one
+func containsContextWord(folded string, words []string) bool {
+	for _, word := range words {
+		w := foldPassword(word)
+		if utf8.RuneCountInString(w) >= minContextWordLength && strings.Contains(folded, w) {
+			return true
+		}
+		parts := strings.FieldsFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= minContextPartLength && strings.Contains(folded, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package argon2password
+
+import (
+	"fmt"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization is a Unicode normalization form applied to the password before
+// hashing, so the same password typed on different systems gives the same bytes,
+// e.g. "café" as NFD on macOS and as NFC on Windows. It is recorded in the encoded
+// hash as the n= parameter, before a pre-hash:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4,n=nfc$salt$hash
+//
+// so verification always applies the same one, and hashes created without it
+// keep verifying the raw bytes. Invalid UTF-8 is passed through unchanged.
+// Hashes with an n= parameter can only be verified by this package.
+type Normalization string
+
+const (
+	// NormalizationNone hashes the password bytes as is, the default.
+	NormalizationNone Normalization = ""
+
+	// NormalizationNFC applies Unicode canonical composition. It only merges
+	// different encodings of the same characters.
+	NormalizationNFC Normalization = "nfc"
+
+	// NormalizationNFKC applies Unicode compatibility composition, which also
+	// folds compatibility characters such as "ﬁ" to "fi" or full-width "Ａ" to "A".
+	// NIST SP 800-63B §5.1.1.2 names NFKC or NFKD for passwords, so this is the
+	// form to choose for new hashes.
+	NormalizationNFKC Normalization = "nfkc"
+)
+
+// Encoded form of the normalization parameter
+const commaNEqual = ",n="
+
+var commaNEqualsBytes = []byte(commaNEqual)
+
+// validNormalization reports whether n is a known normalization form
+func validNormalization(n Normalization) bool {
+	return n == NormalizationNone || n == NormalizationNFC || n == NormalizationNFKC
+}
+
+// parseNormalization parses the value of an n= parameter
+func parseNormalization(value []byte) (Normalization, error) {
+	n := Normalization(value)
+	if n == NormalizationNone || !validNormalization(n) {
+		return NormalizationNone, fmt.Errorf("%w: %q", ErrUnsupportedNormalization, value)
+	}
+	return n, nil
+}
+
+// apply returns the normalized password, a new slice to be wiped by the
+// caller for a normalization form, or password itself for NormalizationNone
+func (n Normalization) apply(password []byte) ([]byte, error) {
+	switch n {
+	case NormalizationNone:
+		return password, nil
+	case NormalizationNFC:
+		// Append always copies, Bytes may return password itself
+		return norm.NFC.Append(nil, password...), nil
+	case NormalizationNFKC:
+		return norm.NFKC.Append(nil, password...), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedNormalization, string(n))
+	}
+}
+
+// argonInput applies the normalization and then the pre-hash to password.
+// Unless both are none the result is a new slice to be wiped by the caller,
+// intermediate copies are wiped here.
+func argonInput(password []byte, n Normalization, p PreHash) ([]byte, error) {
+	normalized, err := n.apply(password)
+	if err != nil {
+		return nil, err
+	}
+	input, err := p.apply(normalized)
+	if n != NormalizationNone && p != PreHashNone {
+		clear(normalized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return input, nil
+}
@@ -0,0 +1,150 @@
+package argon2password
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+)
+
+// redacted replaces a Password wherever it is printed, logged or encoded
+const redacted = "[REDACTED]"
+
+// Password is a plaintext password that redacts itself when printed with fmt,
+// logged with log/slog or encoded as JSON, so it doesn't end up in logs by mistake:
+//
+//	password := argon2password.Password(form.Get("password"))
+//	slog.Info("login", "user", user, "password", password) // password=[REDACTED]
+//	match, err := argon2password.ComparePassword(password, hash)
+//
+// Converting to []byte or string, or indexing it, still gives the plaintext.
+// Call Wipe once done with it.
+type Password []byte
+
+// PasswordFromBytes returns b as a Password without copying it.
+// The Password shares the memory of b, wiping one wipes the other.
+func PasswordFromBytes(b []byte) Password {
+	return Password(b)
+}
+
+// passwordReadSize is the initial buffer of PasswordFromReader, enough for most passwords
+const passwordReadSize = 64
+
+// PasswordFromReader reads all of r, up to maxLen bytes, without leaving partial
+// copies behind: the buffer grows as needed and the outgrown ones are wiped.
+// Input longer than maxLen is wiped and rejected with a *PasswordTooLongError.
+// The content is used as is, a trailing newline is part of the password,
+// use HashFromReader to read a line from stdin or a terminal.
+func PasswordFromReader(r io.Reader, maxLen int) (Password, error) {
+	if maxLen <= 0 {
+		return nil, ErrPasswordMaxLength
+	}
+	// One byte more than allowed to detect longer input without reading all of it,
+	// clamped so a maxLen of math.MaxInt doesn't overflow
+	limit := min(maxLen, math.MaxInt-1) + 1
+	r = io.LimitReader(r, int64(limit))
+	buf := make([]byte, min(passwordReadSize, limit))
+	n := 0
+	for {
+		if n == len(buf) {
+			if n == limit {
+				break
+			}
+			grown := make([]byte, min(2*len(buf), limit))
+			copy(grown, buf)
+			clear(buf)
+			buf = grown
+		}
+		read, err := r.Read(buf[n:])
+		n += read
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			clear(buf)
+			return nil, fmt.Errorf("argon2Password: failed to read password: %w", err)
+		}
+	}
+	if n > maxLen {
+		clear(buf)
+		return nil, &PasswordTooLongError{Length: n, Max: maxLen}
+	}
+	return Password(buf[:n]), nil
+}
+
+// Wipe zeroes the password in place.
+func (p Password) Wipe() {
+	clear(p)
+}
+
+// String returns "[REDACTED]".
+func (p Password) String() string {
+	return redacted
+}
+
+// GoString returns "[REDACTED]", for the %#v verb.
+func (p Password) GoString() string {
+	return redacted
+}
+
+// Format writes "[REDACTED]" for every verb, including %x and %d
+// which would otherwise print the bytes.
+func (p Password) Format(f fmt.State, _ rune) {
+	_, _ = io.WriteString(f, redacted)
+}
+
+// LogValue implements slog.LogValuer and returns "[REDACTED]".
+func (p Password) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// MarshalJSON encodes the password as the string "[REDACTED]".
+func (p Password) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted) //nolint:wrapcheck // a constant string can't fail
+}
+
+// UnmarshalJSON decodes a JSON string into the password. Without it a
+// Password field would be decoded as base64 like other []byte types.
+func (p *Password) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err //nolint:wrapcheck // a JSON syntax or type error
+	}
+	*p = Password(s)
+	return nil
+}
+
+// HashPassword is HashPW for a Password.
+func HashPassword(password Password) (string, error) {
+	hash, err := HashPWBytes(password)
+	if err != nil {
+		return "", fmt.Errorf("argon2Password: failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// HashPasswordWithConfig is HashWithConfig for a Password.
+func HashPasswordWithConfig(password Password, config *Config) (string, error) {
+	hash, err := HashWithConfigBytes(password, config)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// ComparePassword is ComparePW for a Password.
+func ComparePassword(password Password, hash string) (bool, error) {
+	return ComparePWBytes(password, []byte(hash))
+}
+
+// ComparePasswordWithConfig is ComparePWWithConfig for a Password.
+func ComparePasswordWithConfig(password Password, hash string, config *Config) (bool, error) {
+	return ComparePWWithConfigBytes(password, []byte(hash), config)
+}
+
+// CompareDummyPassword is CompareDummy for a Password.
+func CompareDummyPassword(password Password) (bool, error) {
+	return CompareDummyBytes(password)
+}
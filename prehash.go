@@ -0,0 +1,64 @@
+package argon2password
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"fmt"
+)
+
+// PreHash is a versioned transformation applied to the password before Argon2id,
+// recorded in the encoded hash as the ph= parameter, e.g.
+//
+//	$argon2id$v=19$m=65536,t=3,p=4,ph=hmac-sha512-v1$salt$hash
+//
+// so verification always applies the same one, whatever the current Config says.
+// Hashes with a ph= parameter can only be verified by this package.
+type PreHash string
+
+const (
+	// PreHashNone passes the password to Argon2id as is, the default.
+	PreHashNone PreHash = ""
+
+	// PreHashHMACSHA512V1 passes HMAC-SHA-512 of the password to Argon2id,
+	// a fixed 64 bytes whatever the length of the passphrase. The HMAC key is the
+	// constant preHashKeyV1, for domain separation only, it is not a secret.
+	// The algorithm and key of v1 never change, a different one gets a new version.
+	PreHashHMACSHA512V1 PreHash = "hmac-sha512-v1"
+)
+
+// preHashKeyV1 is the HMAC key of PreHashHMACSHA512V1
+var preHashKeyV1 = []byte("gopkg.hlmpn.dev/pkg/argon2password pre-hash v1")
+
+// Encoded form of the pre-hash parameter
+const commaPHEqual = ",ph="
+
+var commaPHEqualsBytes = []byte(commaPHEqual)
+
+// validPreHash reports whether p is a known pre-hash mode
+func validPreHash(p PreHash) bool {
+	return p == PreHashNone || p == PreHashHMACSHA512V1
+}
+
+// parsePreHash parses the value of a ph= parameter
+func parsePreHash(value []byte) (PreHash, error) {
+	p := PreHash(value)
+	if p == PreHashNone || !validPreHash(p) {
+		return PreHashNone, fmt.Errorf("%w: %q", ErrUnsupportedPreHash, value)
+	}
+	return p, nil
+}
+
+// apply returns the Argon2id input for password, a new slice to be wiped by the
+// caller for a pre-hash, or password itself for PreHashNone
+func (p PreHash) apply(password []byte) ([]byte, error) {
+	switch p {
+	case PreHashNone:
+		return password, nil
+	case PreHashHMACSHA512V1:
+		mac := hmac.New(sha512.New, preHashKeyV1)
+		mac.Write(password)
+		return mac.Sum(nil), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedPreHash, string(p))
+	}
+}
@@ -0,0 +1,103 @@
+package argon2password
+
+// Preset is a named, published set of Argon2id parameters.
+// Source and Year record where the parameters come from, so the choice
+// can be audited, e.g. logged at startup or checked in a review.
+//
+//	hash, err := argon2password.HashWithConfig(password, argon2password.PresetOWASP19M2T.Config())
+type Preset struct {
+	Name   string // unique name, see PresetByName
+	Source string // publication the parameters come from
+	Year   int    // year of the publication or of the revision used
+
+	config Config
+}
+
+// Config returns a copy of the preset parameters, safe to modify.
+// MaxMemory and MaxIterations are set high enough to verify hashes created
+// with the preset, use ComparePWWithConfig for presets above ArgonMaxMemory.
+func (p Preset) Config() *Config {
+	config := p.config
+	return &config
+}
+
+// Sources of the presets
+const (
+	presetSourceOWASP   = "OWASP Password Storage Cheat Sheet"
+	presetSourceRFC9106 = "RFC 9106, section 4"
+)
+
+// OWASP recommended minimum configurations, all equivalent protection
+var (
+	// PresetOWASP46M1T is 46 MiB memory, 1 iteration, 1 thread (m=47104, t=1, p=1).
+	PresetOWASP46M1T = newOWASPPreset("owasp-46m-1t", 46*MiB, 1)
+	// PresetOWASP19M2T is 19 MiB memory, 2 iterations, 1 thread (m=19456, t=2, p=1).
+	PresetOWASP19M2T = newOWASPPreset("owasp-19m-2t", 19*MiB, 2)
+	// PresetOWASP12M3T is 12 MiB memory, 3 iterations, 1 thread (m=12288, t=3, p=1).
+	PresetOWASP12M3T = newOWASPPreset("owasp-12m-3t", 12*MiB, 3)
+	// PresetOWASP9M4T is 9 MiB memory, 4 iterations, 1 thread (m=9216, t=4, p=1).
+	PresetOWASP9M4T = newOWASPPreset("owasp-9m-4t", 9*MiB, 4)
+	// PresetOWASP7M5T is 7 MiB memory, 5 iterations, 1 thread (m=7168, t=5, p=1).
+	PresetOWASP7M5T = newOWASPPreset("owasp-7m-5t", 7*MiB, 5)
+)
+
+// RFC 9106 recommended configurations
+var (
+	// PresetRFC9106First is the first recommended option of RFC 9106:
+	// 2 GiB memory, 1 iteration, 4 lanes (m=2097152, t=1, p=4).
+	// It exceeds ArgonMaxMemory, verify its hashes with ComparePWWithConfig.
+	PresetRFC9106First = Preset{
+		Name:   "rfc9106-first",
+		Source: presetSourceRFC9106,
+		Year:   2021,
+		config: presetConfig(2*GiB, 1, 4),
+	}
+	// PresetRFC9106Second is the second recommended option of RFC 9106, for
+	// memory constrained environments: 64 MiB memory, 3 iterations, 4 lanes (m=65536, t=3, p=4).
+	PresetRFC9106Second = Preset{
+		Name:   "rfc9106-second",
+		Source: presetSourceRFC9106,
+		Year:   2021,
+		config: presetConfig(64*MiB, 3, 4),
+	}
+)
+
+// owaspPresets are the OWASP minimums, strongest memory first
+var owaspPresets = []Preset{PresetOWASP46M1T, PresetOWASP19M2T, PresetOWASP12M3T, PresetOWASP9M4T, PresetOWASP7M5T}
+
+// Presets returns all presets, the OWASP ones first.
+func Presets() []Preset {
+	return append(append([]Preset{}, owaspPresets...), PresetRFC9106First, PresetRFC9106Second)
+}
+
+// PresetByName returns the preset with the given name, e.g. "owasp-19m-2t" or "rfc9106-second".
+func PresetByName(name string) (Preset, bool) {
+	for _, preset := range Presets() {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+func newOWASPPreset(name string, memory MemorySize, iterations uint32) Preset {
+	return Preset{
+		Name:   name,
+		Source: presetSourceOWASP,
+		Year:   2025,
+		config: presetConfig(memory, iterations, 1),
+	}
+}
+
+func presetConfig(memory MemorySize, iterations uint32, parallelism uint8) Config {
+	return Config{
+		Memory:            memory,
+		Iterations:        iterations,
+		SaltLength:        ArgonSaltLength, // RFC 9106 and OWASP: 128-bit salt
+		KeyLength:         ArgonKeyLength,  // RFC 9106: 256-bit tag
+		Parallelism:       parallelism,
+		MaxMemory:         max(memory, ArgonMaxMemory),
+		MaxIterations:     max(iterations, ArgonMaxIterations),
+		MaxPasswordLength: ArgonMaxPasswordLength,
+	}
+}
@@ -0,0 +1,137 @@
+package argon2password
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// HashFromReader reads a password from r and hashes it like HashPW.
+//
+// If r is a terminal the password is read without echo. Otherwise the first line
+// is read, without its "\n" or "\r\n" ending, one byte at a time so nothing after
+// it is consumed, e.g. from stdin in provisioning scripts:
+//
+//	hash, err := argon2password.HashFromReader(os.Stdin, 1024)
+//
+// Passwords longer than maxLen bytes are rejected with a *PasswordTooLongError without
+// reading the rest of the line. The password is read into a buffer that grows with
+// the input, each outgrown buffer and the final one are wiped, it never passes
+// through a string.
+func HashFromReader(r io.Reader, maxLen int) (string, error) {
+	password, err := readPasswordLine(r, maxLen)
+	if err != nil {
+		return "", err
+	}
+	defer clear(password)
+	return HashPassword(password)
+}
+
+// HashFromReaderWithConfig is HashFromReader with custom parameters, like HashWithConfig.
+func HashFromReaderWithConfig(r io.Reader, maxLen int, config *Config) (string, error) {
+	if config == nil {
+		return "", ErrConfigNil
+	}
+	password, err := readPasswordLine(r, maxLen)
+	if err != nil {
+		return "", err
+	}
+	defer clear(password)
+	return HashPasswordWithConfig(password, config)
+}
+
+// CompareFromReader reads a password from r like HashFromReader and compares it
+// with hash like ComparePW.
+func CompareFromReader(r io.Reader, maxLen int, hash string) (bool, error) {
+	password, err := readPasswordLine(r, maxLen)
+	if err != nil {
+		return false, err
+	}
+	defer clear(password)
+	return ComparePassword(password, hash)
+}
+
+// readPasswordLine reads a password from a terminal without echo, or the first line of r
+func readPasswordLine(r io.Reader, maxLen int) (Password, error) {
+	if maxLen <= 0 {
+		return nil, ErrPasswordMaxLength
+	}
+	if f, ok := r.(*os.File); ok && term.IsTerminal(int(f.Fd())) { //nolint:gosec // G115, file descriptors fit in an int
+		return readTerminalPassword(int(f.Fd()), maxLen) //nolint:gosec // G115
+	}
+
+	// Room for the password and a "\r\n" line ending, plus one byte to detect longer input,
+	// clamped so a maxLen near math.MaxInt doesn't overflow. The buffer grows as
+	// bytes arrive like in PasswordFromReader, the outgrown ones are wiped.
+	limit := min(maxLen, math.MaxInt-3) + 3 //nolint:mnd // "\r\n" and one more byte
+	buf := make([]byte, min(passwordReadSize, limit))
+	n := 0
+	for n < limit {
+		if n == len(buf) {
+			grown := make([]byte, min(2*len(buf), limit))
+			copy(grown, buf)
+			clear(buf)
+			buf = grown
+		}
+		read, err := r.Read(buf[n : n+1])
+		if read == 1 {
+			n++
+			if buf[n-1] == '\n' {
+				break
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			clear(buf)
+			return nil, fmt.Errorf("argon2Password: failed to read password: %w", err)
+		}
+	}
+	line := trimLineEnding(buf[:n])
+	if err := checkReadLength(buf, len(line), maxLen); err != nil {
+		return nil, err
+	}
+	return Password(line), nil
+}
+
+// readTerminalPassword reads a line from a terminal without echo. The line is
+// bounded by the terminal line discipline, 4095 bytes on Linux, and checked
+// against maxLen like the other input once read.
+func readTerminalPassword(fd, maxLen int) (Password, error) {
+	password, err := term.ReadPassword(fd)
+	if err != nil {
+		clear(password)
+		return nil, fmt.Errorf("argon2Password: failed to read password: %w", err)
+	}
+	if err := checkReadLength(password, len(password), maxLen); err != nil {
+		return nil, err
+	}
+	return Password(password), nil
+}
+
+// checkReadLength wipes buf and returns a *PasswordTooLongError when the password
+// read into it, length bytes long, is over maxLen. The terminal and the line
+// reader both go through it so they enforce the same bound.
+func checkReadLength(buf []byte, length, maxLen int) error {
+	if length <= maxLen {
+		return nil
+	}
+	clear(buf)
+	return &PasswordTooLongError{Length: length, Max: maxLen}
+}
+
+// trimLineEnding removes a trailing "\n" or "\r\n"
+func trimLineEnding(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+		if n := len(line); n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+	}
+	return line
+}
@@ -0,0 +1,187 @@
+package argon2password
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// SecureBuffer holds a password outside the Go heap where the platform allows it,
+// locked into memory with mlock so it isn't written to swap, and wiped on Destroy.
+// Strings can't be wiped and []byte passwords are easily copied by the runtime,
+// a SecureBuffer keeps a single copy the caller controls:
+//
+//	password, err := argon2password.NewSecureBufferFrom(input) // input is wiped
+//	if err != nil {
+//		return err
+//	}
+//	defer password.Destroy()
+//	match, err := argon2password.ComparePWSecure(password, hash)
+//
+// Locking is best effort: it is unavailable on some platforms and limited by
+// RLIMIT_MEMLOCK, see Locked. Argon2id itself still allocates its working memory
+// on the Go heap, which this package can't wipe.
+//
+// Destroy must not be called concurrently with other uses of the buffer.
+type SecureBuffer struct {
+	mu        sync.Mutex
+	data      []byte
+	locked    bool
+	destroyed bool
+}
+
+// NewSecureBuffer allocates a zeroed buffer of size bytes.
+func NewSecureBuffer(size int) (*SecureBuffer, error) {
+	if size < 0 {
+		return nil, ErrSecureBufferSize
+	}
+	data, locked, err := allocSecure(size)
+	if err != nil {
+		return nil, err
+	}
+	b := &SecureBuffer{data: data, locked: locked}
+	// Wipe and release buffers that are never destroyed
+	runtime.SetFinalizer(b, (*SecureBuffer).Destroy)
+	return b, nil
+}
+
+// NewSecureBufferFrom copies src into a new SecureBuffer and wipes src.
+func NewSecureBufferFrom(src []byte) (*SecureBuffer, error) {
+	b, err := NewSecureBuffer(len(src))
+	if err != nil {
+		clear(src)
+		return nil, err
+	}
+	copy(b.data, src)
+	clear(src)
+	return b, nil
+}
+
+// Bytes returns the contents of the buffer, nil after Destroy.
+// The slice is only valid until Destroy and must not be retained.
+func (b *SecureBuffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.destroyed {
+		return nil
+	}
+	return b.data
+}
+
+// Len returns the size of the buffer, 0 after Destroy.
+func (b *SecureBuffer) Len() int {
+	return len(b.Bytes())
+}
+
+// Locked reports whether the buffer is locked into memory.
+func (b *SecureBuffer) Locked() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.locked && !b.destroyed
+}
+
+// Destroy wipes the buffer, unlocks and releases its memory.
+// It is safe to call more than once.
+func (b *SecureBuffer) Destroy() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.destroyed {
+		return
+	}
+	clear(b.data)
+	freeSecure(b.data, b.locked)
+	b.data, b.locked, b.destroyed = nil, false, true
+	runtime.SetFinalizer(b, nil)
+}
+
+// password returns the contents for hashing, or an error for a nil,
+// destroyed or empty buffer
+func (b *SecureBuffer) password() ([]byte, error) {
+	if b == nil {
+		return nil, ErrEmptyPassword
+	}
+	b.mu.Lock()
+	destroyed := b.destroyed
+	b.mu.Unlock()
+	if destroyed {
+		return nil, ErrSecureBufferDestroyed
+	}
+	if len(b.data) == 0 {
+		return nil, ErrEmptyPassword
+	}
+	return b.data, nil
+}
+
+// HashPWSecure is HashPW for a password held in a SecureBuffer.
+// The buffer is not destroyed, the caller does that once done with it.
+func HashPWSecure(password *SecureBuffer) (string, error) {
+	data, err := password.password()
+	if err != nil {
+		return "", err
+	}
+	hash, err := generateHashFromInput(data)
+	runtime.KeepAlive(password)
+	if err != nil {
+		return "", fmt.Errorf("argon2Password: failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// HashWithConfigSecure is HashWithConfig for a password held in a SecureBuffer.
+func HashWithConfigSecure(password *SecureBuffer, config *Config) (string, error) {
+	if config == nil {
+		return "", ErrConfigNil
+	}
+	data, err := password.password()
+	if err != nil {
+		return "", err
+	}
+	hash, err := generateHashFromInputCustom(data, config)
+	runtime.KeepAlive(password)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// ComparePWSecure is ComparePW for a password held in a SecureBuffer.
+func ComparePWSecure(password *SecureBuffer, hash string) (bool, error) {
+	data, err := password.password()
+	if err != nil {
+		return false, err
+	}
+	match, err := ComparePWBytes(data, []byte(hash))
+	runtime.KeepAlive(password)
+	return match, err
+}
+
+// ComparePWWithConfigSecure is ComparePWWithConfig for a password held in a SecureBuffer.
+func ComparePWWithConfigSecure(password *SecureBuffer, hash string, config *Config) (bool, error) {
+	data, err := password.password()
+	if err != nil {
+		return false, err
+	}
+	match, err := ComparePWWithConfigBytes(data, []byte(hash), config)
+	runtime.KeepAlive(password)
+	return match, err
+}
+
+// CompareDummySecure is CompareDummy for a password held in a SecureBuffer.
+func CompareDummySecure(password *SecureBuffer) (bool, error) {
+	data, err := password.password()
+	if err != nil {
+		return false, err
+	}
+	match, err := CompareDummyBytes(data)
+	runtime.KeepAlive(password)
+	return match, err
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package argon2password
+
+// allocSecure allocates on the Go heap where memory can't be mapped and locked
+func allocSecure(size int) ([]byte, bool, error) {
+	return make([]byte, size), false, nil
+}
+
+// freeSecure is a no-op, the memory is released by the garbage collector
+func freeSecure([]byte, bool) {}
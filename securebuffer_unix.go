@@ -0,0 +1,35 @@
+//go:build unix
+
+package argon2password
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// allocSecure maps anonymous memory outside the Go heap, so the garbage
+// collector never copies it, and tries to lock it into memory
+func allocSecure(size int) ([]byte, bool, error) {
+	if size == 0 {
+		return []byte{}, false, nil
+	}
+	data, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, false, fmt.Errorf("argon2Password: failed to allocate SecureBuffer: %w", err)
+	}
+	// Locking fails past RLIMIT_MEMLOCK, the buffer is still usable unlocked
+	locked := unix.Mlock(data) == nil
+	return data, locked, nil
+}
+
+// freeSecure unlocks and unmaps memory from allocSecure
+func freeSecure(data []byte, locked bool) {
+	if len(data) == 0 {
+		return
+	}
+	if locked {
+		_ = unix.Munlock(data)
+	}
+	_ = unix.Munmap(data)
+}
@@ -1,8 +1,11 @@
 package argon2password
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Password validation constants
@@ -30,11 +33,122 @@ func IsValid(password string) bool {
 	return hasLowercase && hasUppercase && hasNumber && hasSpecialChar
 }
 
+// PasswordRequirements are the rules checked by Validate.
+// Lengths are counted in runes, zero values disable a rule.
 type PasswordRequirements struct {
-	HasLowercase bool
-	HasUppercase bool
-	HasNumber    bool
-	HasSpecial   bool
+	HasLowercase bool // at least 1 lowercase letter, same as MinLowercase: 1
+	HasUppercase bool // at least 1 uppercase letter, same as MinUppercase: 1
+	HasNumber    bool // at least 1 digit, same as MinNumbers: 1
+	HasSpecial   bool // at least 1 special character, same as MinSpecial: 1
 	MinLength    int
 	MaxLength    int
+
+	MinLowercase int // minimum count of lowercase letters
+	MinUppercase int // minimum count of uppercase letters
+	MinNumbers   int // minimum count of digits
+	MinSpecial   int // minimum count of punctuation and symbols
+}
+
+// ViolationCode identifies the rule a password failed, stable for use in code
+// and translations, unlike Violation.Message.
+type ViolationCode string
+
+// Violation codes reported by Validate
+const (
+	ViolationTooShort         ViolationCode = "too_short"
+	ViolationTooLong          ViolationCode = "too_long"
+	ViolationMissingLowercase ViolationCode = "missing_lowercase"
+	ViolationMissingUppercase ViolationCode = "missing_uppercase"
+	ViolationMissingNumber    ViolationCode = "missing_number"
+	ViolationMissingSpecial   ViolationCode = "missing_special"
+)
+
+// Violation is a rule a password failed.
+type Violation struct {
+	Code     ViolationCode `json:"code"`
+	Message  string        `json:"message"`  // English description for the user
+	Required int           `json:"required"` // the length or count the rule requires
+	Actual   int           `json:"actual"`   // the length or count found in the password
+}
+
+// Validate checks password against req and returns every rule it fails,
+// in the order of the PasswordRequirements fields, or nil if it passes:
+//
+//	violations := argon2password.Validate(password, argon2password.PasswordRequirements{
+//		MinLength:  12,
+//		MinNumbers: 2,
+//		HasSpecial: true,
+//	})
+//	for _, v := range violations {
+//		fmt.Println(v.Message) // Password must contain at least 2 digits
+//	}
+//
+// Unlike IsValid, lengths are counted in runes and the character classes are
+// Unicode aware, "é" is a lowercase letter and "€" a special character.
+func Validate(password string, req PasswordRequirements) []Violation {
+	var violations []Violation
+	length := utf8.RuneCountInString(password)
+	if req.MinLength > 0 && length < req.MinLength {
+		violations = append(violations, Violation{
+			Code:     ViolationTooShort,
+			Message:  fmt.Sprintf("Password must be at least %d characters long", req.MinLength),
+			Required: req.MinLength,
+			Actual:   length,
+		})
+	}
+	if req.MaxLength > 0 && length > req.MaxLength {
+		violations = append(violations, Violation{
+			Code:     ViolationTooLong,
+			Message:  fmt.Sprintf("Password must be at most %d characters long", req.MaxLength),
+			Required: req.MaxLength,
+			Actual:   length,
+		})
+	}
+
+	var lower, upper, digits, special int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			special++
+		}
+	}
+	classes := []struct {
+		code     ViolationCode
+		has      bool
+		min      int
+		actual   int
+		singular string
+		plural   string
+	}{
+		{ViolationMissingLowercase, req.HasLowercase, req.MinLowercase, lower, "lowercase letter", "lowercase letters"},
+		{ViolationMissingUppercase, req.HasUppercase, req.MinUppercase, upper, "uppercase letter", "uppercase letters"},
+		{ViolationMissingNumber, req.HasNumber, req.MinNumbers, digits, "digit", "digits"},
+		{ViolationMissingSpecial, req.HasSpecial, req.MinSpecial, special, "special character", "special characters"},
+	}
+	for _, c := range classes {
+		required := c.min
+		if c.has && required < 1 {
+			required = 1
+		}
+		if c.actual >= required {
+			continue
+		}
+		name := c.plural
+		if required == 1 {
+			name = c.singular
+		}
+		violations = append(violations, Violation{
+			Code:     c.code,
+			Message:  fmt.Sprintf("Password must contain at least %d %s", required, name),
+			Required: required,
+			Actual:   c.actual,
+		})
+	}
+	return violations
 }
@@ -0,0 +1,134 @@
+package argon2password
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Hash wrapping
+//
+// A legacy hash (bcrypt, or an unsalted MD5, SHA-1 or SHA-2 hex digest) can be
+// hashed again with argon2id without knowing the password, so a whole database
+// can be migrated offline instead of waiting for every user to log in.
+// The inner scheme and its parameters are recorded in the encoded hash:
+//
+//	$argon2id-wrap$v=19$m=65536,t=3,p=4$bcrypt,2b,10,<bcrypt salt>$<salt>$<hash>
+//	$argon2id-wrap$v=19$m=65536,t=3,p=4$sha1$<salt>$<hash>
+//
+// ComparePW recomputes the legacy hash from the password first and verifies it
+// with argon2id second. The argon2id input is the legacy hash in its stored form,
+// the full "$2b$..." string for bcrypt and the lowercase hex digest otherwise.
+// A wrapped hash should be replaced with a plain argon2id hash on the next
+// successful login, see IsWrappedHash.
+
+// WrapLegacyHash hashes legacyHash with argon2id using the default parameters.
+// The scheme is detected from the format of legacyHash if scheme is empty,
+// a 64 character hex digest is taken as SHA-256.
+func WrapLegacyHash(legacyHash string, scheme LegacyScheme) (string, error) {
+	hash, err := wrapLegacyHash(legacyHash, scheme, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// WrapLegacyHashWithConfig is WrapLegacyHash with custom parameters.
+// config.Normalization is ignored, the legacy hash was computed from the raw password.
+func WrapLegacyHashWithConfig(legacyHash string, scheme LegacyScheme, config *Config) (string, error) {
+	if config == nil {
+		return "", ErrConfigNil
+	}
+	hash, err := wrapLegacyHash(legacyHash, scheme, config)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// IsWrappedHash reports whether hash is a wrapped legacy hash.
+// After a successful ComparePW such a hash should be replaced with HashPW(password).
+func IsWrappedHash(hash string) bool {
+	return strings.HasPrefix(hash, argon2idWrapPrefix)
+}
+
+func wrapLegacyHash(legacyHash string, scheme LegacyScheme, config *Config) ([]byte, error) {
+	legacyHash = strings.TrimSpace(legacyHash)
+	if scheme == "" {
+		detected, ok := detectLegacyScheme(legacyHash)
+		if !ok {
+			return nil, ErrUnsupportedLegacyScheme
+		}
+		scheme = detected
+	}
+	params, canonical, err := parseLegacyHash(legacyHash, scheme)
+	if err != nil {
+		return nil, err
+	}
+	if params.exceedsLimits() {
+		return nil, ErrInvalidParams
+	}
+
+	if config == nil {
+		config = currentConfig()
+	}
+	if config.Normalization != NormalizationNone {
+		// The legacy hash was computed from the raw password, it can't be normalized afterwards
+		c := *config
+		c.Normalization = NormalizationNone
+		config = &c
+	}
+	encoded, err := generateEncodedHash(canonical, config)
+	if err != nil {
+		return nil, err
+	}
+	return encodeWrappedHash(params, encoded), nil
+}
+
+// encodeWrappedHash turns an encoded argon2id hash into a wrapped hash
+// by replacing the algorithm and inserting the inner scheme after the parameters
+func encodeWrappedHash(params *legacyParams, encodedHash []byte) []byte {
+	parts := bytes.Split(encodedHash, dollarSignBytes)
+	return bytes.Join([][]byte{nil, argon2idWrapBytes, parts[2], parts[3], params.encode(), parts[4], parts[5]}, dollarSignBytes)
+}
+
+// splitWrappedHash returns the inner scheme of a wrapped hash and the
+// equivalent argon2id hash, which is then parsed and verified as usual
+func splitWrappedHash(encodedHash []byte) (*legacyParams, []byte, error) {
+	parts := bytes.Split(encodedHash, dollarSignBytes)
+	if len(parts) != WrappedEncodedPartCount {
+		return nil, nil, ErrInvalidHashFormat
+	}
+	if !bytes.Equal(parts[1], argon2idWrapBytes) {
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	params, err := decodeLegacyParams(parts[4])
+	if err != nil {
+		return nil, nil, err
+	}
+	return params, bytes.Join([][]byte{nil, argon2idBytes, parts[2], parts[3], parts[5], parts[6]}, dollarSignBytes), nil
+}
+
+// compareWrappedPasswordAndHash computes the legacy hash of password and
+// compares it with the argon2id part of a wrapped hash
+func compareWrappedPasswordAndHash(password []byte, encodedHash []byte, maxMemory MemorySize, maxIterations uint32) (bool, error) {
+	if len(password) == 0 {
+		return false, ErrEmptyPassword
+	}
+	params, argonHash, err := splitWrappedHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+	// Check the limits before spending time on the legacy hash
+	if params.exceedsLimits() {
+		return false, ErrInvalidParams
+	}
+	if _, _, _, _, _, err := decodeArgonHashBytesWithLimits(argonHash, maxMemory, maxIterations); err != nil {
+		return false, err
+	}
+	legacy, err := params.compute(password)
+	if err != nil {
+		return false, err
+	}
+	defer clear(legacy)
+	return compareArgonPasswordAndHashWithLimits(legacy, argonHash, maxMemory, maxIterations)
+}